@@ -0,0 +1,153 @@
+package mycmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// SplitArgs splits a line into arguments like a POSIX shell does.
+// Single quotes preserve all characters, double quotes and backslashes work as in sh,
+// and a word beginning with '#' starts a comment that lasts until the end of the line.
+func SplitArgs(line string) ([]string, error) {
+	const (
+		none = iota
+		single
+		double
+	)
+
+	var (
+		args   = []string{}
+		word   strings.Builder
+		inWord bool
+		quote  = none
+		runes  = []rune(line)
+	)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch quote {
+		case single:
+			if r == '\'' {
+				quote = none
+				continue
+			}
+			word.WriteRune(r)
+			continue
+
+		case double:
+			switch r {
+			case '"':
+				quote = none
+			case '\\':
+				if i+1 < len(runes) && strings.ContainsRune("\"\\$`\n", runes[i+1]) {
+					i++
+					if runes[i] != '\n' {
+						word.WriteRune(runes[i])
+					}
+					continue
+				}
+				word.WriteRune(r)
+			default:
+				word.WriteRune(r)
+			}
+			continue
+		}
+
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case r == '#' && !inWord:
+			// skip the comment
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '\'':
+			quote = single
+			inWord = true
+		case r == '"':
+			quote = double
+			inWord = true
+		case r == '\\':
+			if i+1 >= len(runes) {
+				return nil, fmt.Errorf("unexpected end of input after backslash")
+			}
+			i++
+			if runes[i] == '\n' {
+				// line continuation
+				continue
+			}
+			word.WriteRune(runes[i])
+			inWord = true
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != none {
+		return nil, fmt.Errorf("unterminated quoted string")
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args, nil
+}
+
+// ExpandResponseFiles replaces each argument of the form @path with the arguments in the file at path.
+// The file is split by SplitArgs, so it can contain quotes and comments,
+// and the arguments in it are expanded recursively. A relative path in the file is resolved from the directory of the file.
+// An argument beginning with "@@" is not expanded but passed with the first '@' removed,
+// e.g. "@@user" is passed as "@user".
+func ExpandResponseFiles(args []string) ([]string, error) {
+	return expandResponseFiles(args, "", nil, nil)
+}
+
+// expandResponseFiles expands args in the response file at dir.
+// including has the absolute paths of the response files being expanded, and chain has the same paths as specified.
+func expandResponseFiles(args []string, dir string, including, chain []string) ([]string, error) {
+	expanded := []string{}
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "@@"):
+			expanded = append(expanded, arg[1:])
+			continue
+		case !strings.HasPrefix(arg, "@") || arg == "@":
+			expanded = append(expanded, arg)
+			continue
+		}
+
+		path := arg[1:]
+		if dir != "" && !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		if i := slices.Index(including, abs); i >= 0 {
+			return nil, fmt.Errorf("response file includes itself: %s", strings.Join(append(chain[i:], path), " -> "))
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response file: %w", err)
+		}
+		fileArgs, err := SplitArgs(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid response file %s: %w", path, err)
+		}
+		fileArgs, err = expandResponseFiles(fileArgs, filepath.Dir(path), append(including, abs), append(chain, path))
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, fileArgs...)
+	}
+	return expanded, nil
+}
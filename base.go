@@ -17,6 +17,10 @@ import (
 var _ interface {
 	SubCommand
 	HiddenSupported
+	AliasesSupported
+	DeprecatedSupported
+	FlagSetSupported
+	ResetSupported
 } = (*Base)(nil)
 
 type Base struct {
@@ -25,15 +29,23 @@ type Base struct {
 	shortDescription string
 	shortUsage       string
 	hidden           bool
+	aliases          []string
+	deprecated       string
 	outWriter        io.Writer
 	errWriter        io.Writer
+	inReader         io.Reader
 	parent           Command
+	progress         *Progress
 }
 
 type BaseConfig struct {
 	ShortDescription string
 	ShortUsage       string
 	Hidden           bool
+	Aliases          []string
+	// Deprecated is the message shown when the deprecated command is used, e.g. "use 'foo bar' instead".
+	// The command is deprecated if it is not empty.
+	Deprecated string
 }
 
 func NewBase(name string, cfg BaseConfig) *Base {
@@ -43,9 +55,12 @@ func NewBase(name string, cfg BaseConfig) *Base {
 		shortDescription: cfg.ShortDescription,
 		shortUsage:       cfg.ShortUsage,
 		hidden:           cfg.Hidden,
+		aliases:          cfg.Aliases,
+		deprecated:       cfg.Deprecated,
 
 		outWriter: os.Stdout,
 		errWriter: os.Stderr,
+		inReader:  os.Stdin,
 	}
 	s.fs.SortFlags = false
 
@@ -64,6 +79,12 @@ const baseUsageTemplate = `
 Usage:
 
 {{.CommandNameAndFlags}}
+{{if gt (len .Aliases) 0}}
+Aliases:
+
+  {{join .Aliases ", "}}
+{{- printf "\n"}}
+{{- end -}}
 {{if ne .Flags ""}}
 Flags:
 
@@ -76,6 +97,12 @@ Arguments:
 {{.Arguments}}
 {{- printf "\n"}}
 {{- end -}}
+{{if ne .GlobalFlags ""}}
+Global Flags:
+
+{{.GlobalFlags}}
+{{- printf "\n"}}
+{{- end -}}
 `
 
 func (c *Base) commandNameAndFlags(identNum int) string {
@@ -88,19 +115,26 @@ func (c *Base) commandNameAndFlags(identNum int) string {
 }
 
 func (c *Base) Usage() string {
+	flags, globalFlags := flagUsages(c.parent, c.FS())
 	usageData := map[string]any{
 		"CommandNameAndFlags": c.commandNameAndFlags(2),
-		"Flags":               strings.TrimRight(c.FS().FlagUsages(), "\n"),
+		"Aliases":             c.aliases,
+		"Flags":               flags,
 		"Arguments":           strings.TrimRight(c.FS().ArgUsages(), "\n"),
+		"GlobalFlags":         globalFlags,
 	}
 
-	tmpl := template.Must(template.New("BaseUsage").Parse(baseUsageTemplate))
+	tmpl := template.Must(template.New("BaseUsage").Funcs(usageFuncs).Parse(baseUsageTemplate))
 	var buf bytes.Buffer
 	tmpl.Execute(&buf, usageData)
 
 	return buf.String()
 }
 
+var usageFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
 // // FullName returns full name of command (rootName subName subName ...)
 func FullName(c Command) []string {
 	fullName := []string{}
@@ -119,21 +153,116 @@ func (c Base) FS() *wflag.FlagSet {
 	return c.fs
 }
 
-// Parse parses the flags
-func (c Base) Parse(args []string) error {
-	err := c.fs.Parse(args)
-	if err != nil {
-		return err
+// inheritedFlags returns the persistent flags of the parents.
+// The flags of the nearer parent take precedence.
+func inheritedFlags(parent Command) *pflag.FlagSet {
+	fs := pflag.NewFlagSet("inherited", pflag.ContinueOnError)
+	fs.SortFlags = false
+	for p := parent; p != nil; {
+		if v, ok := p.(PersistentFlagSetSupported); ok {
+			mergeFlags(fs, v.PersistentFS().FlagSet)
+		}
+		sub, ok := p.(SubCommand)
+		if !ok {
+			break
+		}
+		p = sub.Parent()
+	}
+	return fs
+}
+
+// availableFlags returns the flags which c accepts, i.e. its own flags, its persistent flags and the inherited ones.
+func availableFlags(c Command) *pflag.FlagSet {
+	fs := pflag.NewFlagSet("available", pflag.ContinueOnError)
+	fs.SortFlags = false
+	if v, ok := c.(FlagSetSupported); ok {
+		mergeFlags(fs, v.FS().FlagSet)
+	}
+	if v, ok := c.(PersistentFlagSetSupported); ok {
+		mergeFlags(fs, v.PersistentFS().FlagSet)
+	}
+	var parent Command
+	if sub, ok := c.(SubCommand); ok {
+		parent = sub.Parent()
+	}
+	mergeFlags(fs, inheritedFlags(parent))
+	return fs
+}
+
+// mergeFlags adds the flags of src to dst unless their name or shorthand are already used in dst.
+func mergeFlags(dst, src *pflag.FlagSet) {
+	src.VisitAll(func(f *pflag.Flag) {
+		if dst.Lookup(f.Name) != nil {
+			return
+		}
+		if f.Shorthand != "" && dst.ShorthandLookup(f.Shorthand) != nil {
+			return
+		}
+		dst.AddFlag(f)
+	})
+}
+
+// flagUsages returns the usages of the flags in the sets and the usages of the flags inherited from the parents.
+func flagUsages(parent Command, sets ...*wflag.FlagSet) (local string, global string) {
+	inherited := inheritedFlags(parent)
+	localFS := pflag.NewFlagSet("local", pflag.ContinueOnError)
+	localFS.SortFlags = false
+	for _, fs := range sets {
+		localFS.SortFlags = localFS.SortFlags || fs.SortFlags
+		fs.VisitAll(func(f *pflag.Flag) {
+			if inherited.Lookup(f.Name) != f && localFS.Lookup(f.Name) == nil {
+				localFS.AddFlag(f)
+			}
+		})
+	}
+	return strings.TrimRight(localFS.FlagUsages(), "\n"), strings.TrimRight(inherited.FlagUsages(), "\n")
+}
+
+// prompterProvider is implemented by the command which configures the prompt of its descendants.
+type prompterProvider interface {
+	prompter(inReader io.Reader, errWriter io.Writer) *wflag.Prompter
+}
+
+// prompterOf returns the Prompter configured on the nearest parent.
+func prompterOf(parent Command, inReader io.Reader, errWriter io.Writer) *wflag.Prompter {
+	for p := parent; p != nil; {
+		if v, ok := p.(prompterProvider); ok {
+			return v.prompter(inReader, errWriter)
+		}
+		sub, ok := p.(SubCommand)
+		if !ok {
+			break
+		}
+		p = sub.Parent()
 	}
 	return nil
 }
 
+// Parse parses the flags, including the persistent flags inherited from the parents.
+func (c Base) Parse(args []string) error {
+	return c.parseFlags(args, inheritedFlags(c.parent))
+}
+
+// parseFlags parses the flags of c and the inherited ones, which are not added to the FlagSet of c.
+func (c Base) parseFlags(args []string, inherited *pflag.FlagSet) error {
+	c.fs.SetPrompter(prompterOf(c.parent, c.inReader, c.errWriter))
+	return c.fs.ParseWithInherited(args, inherited)
+}
+
+// Reset restores the flags and arguments to their default values so that the command can be parsed again,
+// and stops the progress left by the previous execution.
+func (c *Base) Reset() {
+	c.fs.Reset()
+	c.stopProgress()
+}
+
 // IsHelpRequested will return true when the help was requested in Parse.
 func (c Base) IsHelpRequested(err error) bool {
 	return errors.Is(err, wflag.ErrHelp)
 }
 
 // Execute executes the main processing of the command.
+// The command embedding Base must implement Execute or ExecuteContext.
 func (c Base) Execute() int {
 	panic(fmt.Errorf("%s is not implemented", c.name))
 }
@@ -145,12 +274,16 @@ func (c Base) ExecuteContext(ctx context.Context) int {
 
 // Print writes to OutWriter.
 func (c *Base) Print(msg string) {
-	fmt.Fprint(c.outWriter, msg)
+	c.withProgressPaused(func() {
+		fmt.Fprint(c.outWriter, msg)
+	})
 }
 
 // PrintError writes to ErrWriter.
 func (c *Base) PrintError(msg string) {
-	fmt.Fprint(c.errWriter, msg)
+	c.withProgressPaused(func() {
+		fmt.Fprint(c.errWriter, msg)
+	})
 }
 
 // OutWriter returns the standard output writer.
@@ -173,6 +306,25 @@ func (c *Base) SetErrWriter(w io.Writer) {
 	c.errWriter = w
 }
 
+// InReader returns the standard input reader.
+func (c *Base) InReader() io.Reader {
+	return c.inReader
+}
+
+// SetInReader sets the standard input reader.
+func (c *Base) SetInReader(r io.Reader) {
+	c.inReader = r
+}
+
+// OpenInput opens the file to read.
+// If name is "-", the standard input reader is returned, which is not closed by Close.
+func (c *Base) OpenInput(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(c.inReader), nil
+	}
+	return os.Open(name)
+}
+
 // Parent returns the parent command.
 func (c *Base) Parent() Command {
 	return c.parent
@@ -184,6 +336,17 @@ func (c *Base) SetParent(parent Command) {
 }
 
 // If Hidden is true, this command will not be displayed in Usage.
+// Deprecated commands are also hidden.
 func (c *Base) Hidden() bool {
-	return c.hidden
+	return c.hidden || c.deprecated != ""
+}
+
+// Deprecated returns the deprecation message, or "" if the command is not deprecated.
+func (c *Base) Deprecated() string {
+	return c.deprecated
+}
+
+// Aliases returns the alternative names of the command.
+func (c *Base) Aliases() []string {
+	return c.aliases
 }
@@ -5,72 +5,146 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"strings"
 	"text/template"
+
+	"github.com/kmio11/mycmd/wflag"
+	"github.com/spf13/pflag"
 )
 
 var _ interface {
 	ParentCommand
 	HelpSupported
 	HiddenSupported
+	ResetSupported
+	PersistentFlagSetSupported
+	HelpTopicsSupported
 } = (*ParentBase)(nil)
 
 type ParentBase struct {
 	*Base
+	persistentFS  *wflag.FlagSet
 	commands      []Command
+	topics        []HelpTopic
 	help          *Help
 	parsedCommand Command
 }
 
 func NewParentBase(name string, cfg BaseConfig) *ParentBase {
 	c := &ParentBase{
-		Base: NewBase(name, cfg),
+		Base:         NewBase(name, cfg),
+		persistentFS: wflag.NewFlagSet(name, pflag.ContinueOnError),
 	}
+	c.persistentFS.SortFlags = false
+	c.fs.SetInterspersed(false)
 	c.help = NewHelp(c)
 	return c
 }
 
-// AddCommands add subcommands
+// PersistentFS returns FlagSet whose flags are available in this command and all its subcommands.
+func (c *ParentBase) PersistentFS() *wflag.FlagSet {
+	return c.persistentFS
+}
+
+// AddCommands adds subcommands after the ones already added.
 func (c *ParentBase) AddCommands(commands ...Command) *ParentBase {
-	c.commands = commands
+	c.commands = append(c.commands, commands...)
 	for _, command := range commands {
 		if sub, ok := command.(SubCommand); ok {
 			sub.SetParent(c)
 		}
 		command.SetOutWriter(c.outWriter)
 		command.SetErrWriter(c.errWriter)
+		if v, ok := command.(InReaderSupported); ok {
+			v.SetInReader(c.inReader)
+		}
 	}
 
 	return c
 }
 
+// AddHelpTopics adds help topics, which are shown by the help command but are not executable.
+func (c *ParentBase) AddHelpTopics(topics ...HelpTopic) *ParentBase {
+	c.topics = append(c.topics, topics...)
+	return c
+}
+
+// HelpTopics returns the help topics.
+func (c *ParentBase) HelpTopics() []HelpTopic {
+	return c.topics
+}
+
 const parentBaseUsageTemplate = `
 Usage:
 
   {{.FullName}} {{.ShortUsage}}
-{{ if gt (len .Commands) 0}}
+{{ if gt (len .Aliases) 0}}
+Aliases:
+
+  {{join .Aliases ", "}}
+{{ end}}
+{{- if gt (len .Commands) 0}}
 Commands:
 {{ range .Commands}}
   {{.}}
 {{- end}}
 {{- end}}
+{{- if gt (len .Topics) 0}}
+
+Additional help topics:
+{{ range .Topics}}
+  {{.}}
+{{- end}}
+{{- end}}
+{{ if ne .Flags ""}}
+Flags:
 
+{{.Flags}}
+{{ end}}
+{{- if ne .GlobalFlags ""}}
+Global Flags:
+
+{{.GlobalFlags}}
+{{ end}}
 Use '{{.FullName}} {{.Help}} <command>' for more details on a command.
+Use '{{.FullName}} {{.Help}} --all' to list all the commands.
+{{- if gt (len .Topics) 0}}
+Use '{{.FullName}} {{.Help}} <topic>' for more information about that topic.
+{{- end}}
 `
 
 func (c *ParentBase) commandsWithShortDescription() []string {
-	const adjuster = "!#!"
-	maxCmdNameLen := 0
-	lines := []string{}
+	names, descriptions := []string{}, []string{}
 	for _, sub := range c.commands {
 		if v, ok := sub.(HiddenSupported); ok && v.Hidden() {
 			continue
 		}
+		names = append(names, sub.Name())
+		descriptions = append(descriptions, sub.ShortDescription())
+	}
+	return alignDescriptions(names, descriptions)
+}
 
-		if maxCmdNameLen < len(sub.Name()) {
-			maxCmdNameLen = len(sub.Name())
+func (c *ParentBase) topicsWithShortDescription() []string {
+	names, descriptions := []string{}, []string{}
+	for _, topic := range c.topics {
+		names = append(names, topic.Name)
+		descriptions = append(descriptions, topic.ShortDescription)
+	}
+	return alignDescriptions(names, descriptions)
+}
+
+// alignDescriptions returns the lines of the names followed by the descriptions aligned.
+func alignDescriptions(names, descriptions []string) []string {
+	const adjuster = "!#!"
+	maxCmdNameLen := 0
+	lines := []string{}
+	for i, name := range names {
+		if maxCmdNameLen < len(name) {
+			maxCmdNameLen = len(name)
 		}
-		lines = append(lines, fmt.Sprintf("%s%s%s", sub.Name(), adjuster, sub.ShortDescription()))
+		lines = append(lines, fmt.Sprintf("%s%s%s", name, adjuster, descriptions[i]))
 	}
 
 	alignedLines := []string{}
@@ -85,66 +159,103 @@ func (c *ParentBase) commandsWithShortDescription() []string {
 }
 
 func (c *ParentBase) Usage() string {
+	flags, globalFlags := flagUsages(c.parent, c.persistentFS, c.FS())
 	usageData := map[string]any{
-		"FullName":   strings.Join(FullName(c), " "),
-		"ShortUsage": "<command> [flags] [arguments]",
-		"Commands":   c.commandsWithShortDescription(),
-		"Help":       c.help.Name(),
+		"FullName":    strings.Join(FullName(c), " "),
+		"ShortUsage":  "<command> [flags] [arguments]",
+		"Aliases":     c.aliases,
+		"Commands":    c.commandsWithShortDescription(),
+		"Topics":      c.topicsWithShortDescription(),
+		"Flags":       flags,
+		"GlobalFlags": globalFlags,
+		"Help":        c.help.Name(),
 	}
 
-	tmpl := template.Must(template.New("ParentBaseUsage").Parse(parentBaseUsageTemplate))
+	tmpl := template.Must(template.New("ParentBaseUsage").Funcs(usageFuncs).Parse(parentBaseUsageTemplate))
 	var buf bytes.Buffer
 	tmpl.Execute(&buf, usageData)
 
 	return buf.String()
 }
 
-// Parse parses the flags
+// Parse parses the flags preceding the subcommand, then the subcommand and the rest of args.
 func (c *ParentBase) Parse(args []string) error {
+	// the own persistent flags take precedence over the ones of the parents.
+	available := pflag.NewFlagSet("available", pflag.ContinueOnError)
+	mergeFlags(available, c.persistentFS.FlagSet)
+	mergeFlags(available, inheritedFlags(c.parent))
+	err := c.Base.parseFlags(args, available)
+	if err != nil {
+		return err
+	}
+	args = c.fs.Args()
+
 	if len(args) == 0 {
-		c.help.Parse(args)
 		c.parsedCommand = c.help
-		return nil
+		return c.help.Parse(args)
 	}
 
 	subcommand := args[0]
 	if subcommand == c.help.Name() {
-		c.help.Parse(args[1:])
 		c.parsedCommand = c.help
-		return nil
-	}
-
-	for _, sub := range c.commands {
-		sub := sub
-		if subcommand == sub.Name() {
-			c.parsedCommand = sub
-			break
-		}
+		return c.help.Parse(args[1:])
 	}
 
+	c.parsedCommand = findCommand(c, subcommand)
 	if c.parsedCommand != nil {
-		err := c.parsedCommand.Parse(args[1:])
+		err = c.parsedCommand.Parse(args[1:])
 		if err != nil {
 			if c.parsedCommand.IsHelpRequested(err) {
-				c.help.Parse(args)
+				c.help.Parse(args[:1])
 				c.parsedCommand = c.help
 				return nil
 			}
 			return err
 		}
+		if v, ok := c.parsedCommand.(DeprecatedSupported); ok && v.Deprecated() != "" {
+			c.PrintError(fmt.Sprintf("Command %q is deprecated, %s\n", subcommand, v.Deprecated()))
+		}
 		return nil
 	}
 
+	if findHelpTopic(c, subcommand) != nil {
+		return fmt.Errorf("%s is a help topic, not a command. Run '%s %s'", subcommand, strings.Join(FullName(c.help), " "), subcommand)
+	}
 	return fmt.Errorf("unknown command (%s)", subcommand)
 }
 
+func (c *ParentBase) parsed() Command {
+	return c.parsedCommand
+}
+
+// Reset forgets the result of the previous parsing, including the one of subcommands.
+func (c *ParentBase) Reset() {
+	c.Base.Reset()
+	c.persistentFS.Reset()
+	c.parsedCommand = nil
+	if c.help != nil {
+		c.help.Reset()
+	}
+	for _, sub := range c.commands {
+		if v, ok := sub.(ResetSupported); ok {
+			v.Reset()
+		}
+	}
+}
+
 // Execute executes the main processing of the command.
 func (c *ParentBase) Execute() int {
+	defer stopProgress(c.parsedCommand)
 	return c.parsedCommand.Execute()
 }
 
 // ExecuteContext is the same as Execute() but accept a context as an argument.
+// The logger of the subcommand is passed through the context.
 func (c *ParentBase) ExecuteContext(ctx context.Context) int {
+	if v, ok := c.parsedCommand.(interface{ Logger() *slog.Logger }); ok {
+		ctx = ContextWithLogger(ctx, v.Logger())
+	}
+	defer stopProgress(c.parsedCommand)
 	return c.parsedCommand.ExecuteContext(ctx)
 }
 
@@ -160,6 +271,18 @@ func (c *ParentBase) passWriterToSubCmds() {
 	}
 }
 
+// passReaderToSubCmds sets the same Reader as itself to its subcommands
+func (c *ParentBase) passReaderToSubCmds() {
+	if c.help != nil {
+		c.help.SetInReader(c.inReader)
+	}
+	for _, s := range c.commands {
+		if v, ok := s.(InReaderSupported); ok {
+			v.SetInReader(c.inReader)
+		}
+	}
+}
+
 // SetOutWriter sets the standard output writer.
 func (c *ParentBase) SetOutWriter(w io.Writer) {
 	c.Base.SetOutWriter(w)
@@ -172,6 +295,12 @@ func (c *ParentBase) SetErrWriter(w io.Writer) {
 	c.passWriterToSubCmds()
 }
 
+// SetInReader sets the standard input reader.
+func (c *ParentBase) SetInReader(r io.Reader) {
+	c.Base.SetInReader(r)
+	c.passReaderToSubCmds()
+}
+
 // Commands returns subcommands.
 func (c *ParentBase) Commands() []Command {
 	return c.commands
@@ -0,0 +1,517 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+type (
+	commandSpec struct {
+		Name     string
+		Type     string
+		Package  string
+		Dir      string
+		Short    string
+		IsParent bool
+		WithTest bool
+		Flags    []flagSpec
+		Args     []argSpec
+	}
+
+	flagSpec struct {
+		Name      string
+		Shorthand string
+		Field     string
+		GoType    string
+		Func      string
+		Default   string
+		Usage     string
+	}
+
+	argSpec struct {
+		Index int
+		Name  string
+		Field string
+		Usage string
+	}
+
+	file struct {
+		path      string
+		content   []byte
+		overwrite bool
+	}
+)
+
+// flagTypes maps the type name of --flag to the Go type, the FlagSet method and the default value.
+var flagTypes = map[string][3]string{
+	"string":   {"string", "String", `""`},
+	"bool":     {"bool", "Bool", "false"},
+	"int":      {"int", "Int", "0"},
+	"duration": {"time.Duration", "Duration", "0"},
+	"strings":  {"[]string", "StringSlice", "nil"},
+}
+
+// parseFlagSpec parses name[,shorthand]:type:usage.
+func parseFlagSpec(s string) (flagSpec, error) {
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) != 3 || parts[0] == "" {
+		return flagSpec{}, fmt.Errorf("invalid --flag (%s): must be name[,shorthand]:type:usage", s)
+	}
+	name, shorthand, _ := strings.Cut(parts[0], ",")
+	t, ok := flagTypes[parts[1]]
+	if !ok {
+		return flagSpec{}, fmt.Errorf("invalid --flag (%s): unknown type %s", s, parts[1])
+	}
+	f := flagSpec{
+		Name:      name,
+		Shorthand: shorthand,
+		Field:     fieldName(name),
+		GoType:    t[0],
+		Func:      t[1],
+		Default:   t[2],
+		Usage:     parts[2],
+	}
+	if shorthand != "" {
+		f.Func += "P"
+	}
+	return f, nil
+}
+
+// fieldName converts the kebab-case name to the CamelCase name.
+func fieldName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if r == '-' || r == '_' || r == ' ' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// validateName checks that name can be used as the name of a command and of the generated type.
+func validateName(name string) error {
+	for _, r := range name {
+		if r != '-' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return fmt.Errorf("invalid name (%s): must consist of letters, digits and '-'", name)
+		}
+	}
+	if f := fieldName(name); !token.IsIdentifier(f) {
+		return fmt.Errorf("invalid name (%s): %q is not a valid Go identifier", name, f)
+	}
+	return nil
+}
+
+func typeName(name string) string {
+	return fieldName(name) + "Command"
+}
+
+func (s *commandSpec) ShortUsage() string {
+	if s.IsParent {
+		return ""
+	}
+	usage := []string{}
+	for _, f := range s.Flags {
+		v := ""
+		if f.GoType != "bool" {
+			v = " " + f.Name
+		}
+		usage = append(usage, fmt.Sprintf("[--%s%s]", f.Name, v))
+	}
+	for _, a := range s.Args {
+		usage = append(usage, fmt.Sprintf("<%s>", a.Name))
+	}
+	return strings.Join(usage, " ")
+}
+
+func (s *commandSpec) UsesTime() bool {
+	for _, f := range s.Flags {
+		if f.GoType == "time.Duration" {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *commandSpec) TestArgs() string {
+	args := []string{}
+	for _, a := range s.Args {
+		args = append(args, strconv.Quote(a.Name))
+	}
+	return strings.Join(args, ", ")
+}
+
+func (s *commandSpec) FileName() string {
+	return strings.ReplaceAll(s.Name, "-", "_")
+}
+
+const leafTemplate = `package {{.Package}}
+
+import (
+	"fmt"
+{{- if .UsesTime}}
+	"time"
+{{- end}}
+
+	fv "github.com/kmio11/flag-validator/pflag-validator"
+	"github.com/kmio11/mycmd"
+)
+
+// {{.Type}} is the {{.Name}} command.
+type {{.Type}} struct {
+	*mycmd.Base
+{{if or .Flags .Args}}
+{{- range .Flags}}
+	flag{{.Field}} *{{.GoType}}
+{{- end}}
+{{- range .Args}}
+	arg{{.Field}} *string
+{{- end}}
+{{end -}}
+}
+
+func New{{.Type}}() *{{.Type}} {
+	cmd := &{{.Type}}{
+		Base: mycmd.NewBase(
+			{{printf "%q" .Name}},
+			mycmd.BaseConfig{
+				ShortDescription: {{printf "%q" .Short}},
+				ShortUsage:       {{printf "%q" .ShortUsage}},
+			},
+		),
+	}
+{{if .Flags}}
+	// set flags
+{{- range .Flags}}
+	cmd.flag{{.Field}} = cmd.FS().{{.Func}}({{printf "%q" .Name}}, {{if .Shorthand}}{{printf "%q" .Shorthand}}, {{end}}{{.Default}}, {{printf "%q" .Usage}})
+{{- end}}
+{{end}}
+{{- if .Args}}
+	// set arguments
+{{- range .Args}}
+	cmd.arg{{.Field}} = cmd.FS().ArgString({{.Index}}, {{printf "%q" .Name}}, {{printf "%q" .Usage}})
+{{- end}}
+{{end}}
+	// set validation rules
+	cmd.FS().SetValidationRules(
+		fv.NumberOfArgs({{len .Args}}),
+	)
+
+	return cmd
+}
+
+func (c {{.Type}}) Execute() int {
+	// TODO: implement the command.
+	c.Print(fmt.Sprintln("{{.Name}} is not implemented yet"))
+	return 0
+}
+`
+
+const parentTemplate = `package {{.Package}}
+
+import (
+	"github.com/kmio11/mycmd"
+)
+
+// {{.Type}} is the {{.Name}} command which has subcommands.
+type {{.Type}} struct {
+	*mycmd.ParentBase
+}
+
+func New{{.Type}}() *{{.Type}} {
+	cmd := &{{.Type}}{
+		ParentBase: mycmd.NewParentBase(
+			{{printf "%q" .Name}},
+			mycmd.BaseConfig{
+				ShortDescription: {{printf "%q" .Short}},
+			},
+		).AddCommands(
+			// TODO: add subcommands.
+		),
+	}
+
+	return cmd
+}
+`
+
+const testTemplate = `package {{.Package}}
+
+import (
+	"testing"
+
+	"github.com/kmio11/mycmd"
+	"github.com/kmio11/mycmd/testutils"
+)
+
+func Test{{.Type}}_Usage(t *testing.T) {
+	testdata := testutils.NewTestData(t, t.Name())
+	tests := []testutils.TestCaseUsage{
+		{
+			Name: "usage",
+		},
+	}
+
+	testutils.RunTestCommand_Usage(
+		t, tests, testdata,
+		func() mycmd.Command {
+			return New{{.Type}}()
+		},
+		nil,
+	)
+}
+{{if not .IsParent}}
+func Test{{.Type}}_Execute(t *testing.T) {
+	testdata := testutils.NewTestData(t, t.Name())
+	tests := []testutils.TestCaseExecute{
+		{
+			Name: "default",
+			Args: []string{ {{- .TestArgs -}} },
+			Want: 0,
+		},
+	}
+
+	testutils.RunTestCommand_Execute(
+		t, tests, testdata,
+		func() mycmd.Command {
+			return New{{.Type}}()
+		},
+		nil,
+	)
+}
+{{end -}}
+`
+
+// generate returns the source files of the command and its test.
+func generate(spec *commandSpec) ([]file, error) {
+	tmpl := leafTemplate
+	if spec.IsParent {
+		tmpl = parentTemplate
+	}
+
+	files := []file{}
+	src, err := execute(tmpl, spec)
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, file{
+		path:    filepath.Join(spec.Dir, spec.FileName()+".go"),
+		content: src,
+	})
+
+	if spec.WithTest {
+		src, err := execute(testTemplate, spec)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file{
+			path:    filepath.Join(spec.Dir, spec.FileName()+"_test.go"),
+			content: src,
+		})
+	}
+	return files, nil
+}
+
+func execute(tmpl string, data any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := template.Must(template.New("").Parse(tmpl)).Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated code is invalid: %w", err)
+	}
+	return src, nil
+}
+
+// parseDir parses the go files except tests in dir.
+func parseDir(fset *token.FileSet, dir string, mode parser.Mode) (map[string]*ast.File, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]*ast.File{}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".go") || strings.HasSuffix(e.Name(), "_test.go") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		f, err := parser.ParseFile(fset, path, nil, mode)
+		if err != nil {
+			return nil, err
+		}
+		files[path] = f
+	}
+	return files, nil
+}
+
+// packageName returns the package name of the go files in dir, or the base name of dir if there is no go file.
+func packageName(dir string) (string, error) {
+	files, err := parseDir(token.NewFileSet(), dir, parser.PackageClauseOnly)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	for _, f := range files {
+		return f.Name.Name, nil
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(filepath.Base(abs), "-", ""), nil
+}
+
+// wire adds the constructor of the new command to the AddCommands call in the parent constructor.
+func wire(parentDir string, parentFunc string, spec *commandSpec) (file, error) {
+	fset := token.NewFileSet()
+	files, err := parseDir(fset, parentDir, parser.ParseComments)
+	if err != nil {
+		return file{}, err
+	}
+
+	for path, f := range files {
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Name.Name != parentFunc || fn.Recv != nil {
+				continue
+			}
+			call := findAddCommands(fn)
+			if call == nil {
+				return file{}, fmt.Errorf("AddCommands is not called in %s", parentFunc)
+			}
+
+			src, err := os.ReadFile(path)
+			if err != nil {
+				return file{}, err
+			}
+			constructor := fmt.Sprintf("New%s()", spec.Type)
+			importPath := ""
+			if f.Name.Name != spec.Package {
+				constructor = fmt.Sprintf("%s.%s", spec.Package, constructor)
+				importPath, err = importPathOf(spec.Dir)
+				if err != nil {
+					return file{}, err
+				}
+			}
+
+			src = insertArg(fset, src, call, constructor)
+			if importPath != "" && !hasImport(f, importPath) {
+				src = insertImport(fset, src, f, importPath)
+			}
+			formatted, err := format.Source(src)
+			if err != nil {
+				return file{}, fmt.Errorf("failed to update %s: %w", path, err)
+			}
+			return file{path: path, content: formatted, overwrite: true}, nil
+		}
+	}
+	return file{}, fmt.Errorf("function %s is not found in %s", parentFunc, parentDir)
+}
+
+func findAddCommands(fn *ast.FuncDecl) *ast.CallExpr {
+	var found *ast.CallExpr
+	ast.Inspect(fn, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || found != nil {
+			return found == nil
+		}
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "AddCommands" {
+			found = call
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// insertArg inserts arg as the last argument of call, keeping the layout of the arguments.
+func insertArg(fset *token.FileSet, src []byte, call *ast.CallExpr, arg string) []byte {
+	rparen := fset.Position(call.Rparen).Offset
+	if len(call.Args) == 0 {
+		if fset.Position(call.Lparen).Line != fset.Position(call.Rparen).Line {
+			return splice(src, rparen, arg+",\n")
+		}
+		return splice(src, rparen, arg)
+	}
+
+	last := call.Args[len(call.Args)-1]
+	end := fset.Position(last.End()).Offset
+	if fset.Position(last.End()).Line == fset.Position(call.Rparen).Line {
+		return splice(src, end, ", "+arg)
+	}
+	// multi-line arguments end with a comma.
+	comma := bytes.IndexByte(src[end:], ',')
+	return splice(src, end+comma+1, "\n"+arg+",")
+}
+
+func hasImport(f *ast.File, path string) bool {
+	for _, spec := range f.Imports {
+		if p, err := strconv.Unquote(spec.Path.Value); err == nil && p == path {
+			return true
+		}
+	}
+	return false
+}
+
+func insertImport(fset *token.FileSet, src []byte, f *ast.File, path string) []byte {
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if ok && gen.Tok == token.IMPORT && gen.Lparen.IsValid() {
+			// the last group is usually the one of the non standard packages.
+			return splice(src, fset.Position(gen.Rparen).Offset, strconv.Quote(path)+"\n")
+		}
+	}
+	return splice(src, fset.Position(f.Name.End()).Offset, "\n\nimport "+strconv.Quote(path))
+}
+
+func splice(src []byte, offset int, s string) []byte {
+	out := make([]byte, 0, len(src)+len(s))
+	out = append(out, src[:offset]...)
+	out = append(out, s...)
+	return append(out, src[offset:]...)
+}
+
+// importPathOf returns the import path of dir from the go.mod in dir or its parents.
+func importPathOf(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for d := abs; ; d = filepath.Dir(d) {
+		data, err := os.ReadFile(filepath.Join(d, "go.mod"))
+		if err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				if module, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+					rel, err := filepath.Rel(d, abs)
+					if err != nil {
+						return "", err
+					}
+					module = strings.Trim(strings.TrimSpace(module), `"`)
+					if rel == "." {
+						return module, nil
+					}
+					return module + "/" + filepath.ToSlash(rel), nil
+				}
+			}
+			return "", fmt.Errorf("module path is not found in %s", filepath.Join(d, "go.mod"))
+		}
+		if filepath.Dir(d) == d {
+			return "", fmt.Errorf("go.mod is not found for %s", dir)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+// Command mycmd generates the skeletons of commands built with github.com/kmio11/mycmd.
+package main
+
+import (
+	"os"
+
+	"github.com/kmio11/mycmd"
+)
+
+func main() {
+	rootCmd := NewRootCommand()
+	os.Exit(rootCmd.ParseAndExecute(os.Args[1:]))
+}
+
+func NewRootCommand() *mycmd.Root {
+	return mycmd.NewRoot("mycmd").AddCommands(
+		NewNewCommand(),
+	)
+}
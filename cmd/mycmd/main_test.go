@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/kmio11/mycmd"
+	"github.com/kmio11/mycmd/testutils"
+)
+
+func TestRoot_ParseAndExecute(t *testing.T) {
+	testdata := testutils.NewTestData(t, t.Name())
+	testdata.CheckOrphanGoldens(t)
+	tests := []testutils.TestCaseRootParseAndExecute{
+		{
+			Name: "new_leaf_in_other_package",
+			Args: []string{
+				"new", "lint",
+				"--dir", "testdata/fixture/cmd",
+				"--parent-dir", "testdata/fixture",
+				"--parent-func", "NewRootCommand",
+				"--short", "report suspicious constructs",
+				"--flag", "fix:bool:apply the suggested fixes",
+				"--flag", "config,c:string:path to the config file",
+				"--flag", "timeout:duration:timeout of the analysis",
+				"--arg", "packages:the packages to analyze",
+				"--dry-run",
+			},
+			Want: 0,
+		},
+		{
+			Name: "new_parent_in_same_package",
+			Args: []string{
+				"new", "plugin",
+				"--kind", "parent",
+				"--dir", "testdata/fixture",
+				"--parent-func", "NewRootCommand",
+				"--short", "manage plugins",
+				"--no-test",
+				"--dry-run",
+			},
+			Want: 0,
+		},
+		{
+			Name: "unknown_parent_func",
+			Args: []string{
+				"new", "lint", "--dir", "testdata/fixture", "--parent-func", "NewUnknown", "-n",
+			},
+			Want: 1,
+		},
+		{
+			Name: "invalid_flag_spec",
+			Args: []string{
+				"new", "lint", "--dir", "testdata/fixture", "--flag", "fix:float:fix", "-n",
+			},
+			Want: 2,
+		},
+		{
+			Name: "invalid_name_character",
+			Args: []string{
+				"new", "lint.v2", "--dir", "testdata/fixture", "-n",
+			},
+			Want: 2,
+		},
+		{
+			Name: "invalid_name_identifier",
+			Args: []string{
+				"new", "2fa", "--dir", "testdata/fixture", "-n",
+			},
+			Want: 2,
+		},
+	}
+
+	testutils.RunTestRoot_ParseAndExecute(
+		t, tests, testdata,
+		func() mycmd.Command {
+			return NewRootCommand()
+		},
+		nil,
+	)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	fv "github.com/kmio11/flag-validator/pflag-validator"
+	"github.com/kmio11/mycmd"
+)
+
+// NewCommand generates a new command.
+type NewCommand struct {
+	*mycmd.Base
+
+	flagKind       *string
+	flagDir        *string
+	flagShort      *string
+	flagFlags      *[]string
+	flagArgs       *[]string
+	flagParentDir  *string
+	flagParentFunc *string
+	flagNoTest     *bool
+	flagDryRun     *bool
+	argName        *string
+}
+
+func NewNewCommand() *NewCommand {
+	cmd := &NewCommand{
+		Base: mycmd.NewBase(
+			"new",
+			mycmd.BaseConfig{
+				ShortDescription: "generate a new command",
+				ShortUsage: `[--kind leaf|parent] [--dir dir] [--short description]
+					[--flag name[,shorthand]:type:usage]... [--arg name:usage]...
+					[--parent-dir dir --parent-func func] <name>`,
+			},
+		),
+	}
+
+	// set flags
+	cmd.flagKind = cmd.FS().Enum("kind", "leaf", []string{"leaf", "parent"}, "kind of the command (leaf|parent)")
+	cmd.flagDir = cmd.FS().String("dir", ".", "directory where the command is generated")
+	cmd.flagShort = cmd.FS().String("short", "", "short description of the command")
+	cmd.flagFlags = cmd.FS().StringArray("flag", nil,
+		"flag of the command as name[,shorthand]:type:usage\ntype is one of string, bool, int, duration and strings",
+	)
+	cmd.flagArgs = cmd.FS().StringArray("arg", nil, "argument of the command as name:usage")
+	cmd.flagParentDir = cmd.FS().String("parent-dir", "", "directory of the parent command (default: --dir)")
+	cmd.flagParentFunc = cmd.FS().String("parent-func", "", "constructor of the parent command whose AddCommands call the new command is added to")
+	cmd.flagNoTest = cmd.FS().Bool("no-test", false, "do not generate the test")
+	cmd.flagDryRun = cmd.FS().BoolP("dry-run", "n", false, "print the generated files instead of writing them")
+
+	// set arguments
+	cmd.argName = cmd.FS().ArgString(0, "name", "name of the command")
+
+	// set validation rules
+	cmd.FS().SetValidationRules(
+		fv.NumberOfArgs(1),
+	)
+
+	return cmd
+}
+
+func (c NewCommand) Execute() int {
+	spec, err := c.spec()
+	if err != nil {
+		c.PrintError(fmt.Sprintf("ERROR : %s\n", err))
+		return 2
+	}
+
+	files, err := generate(spec)
+	if err != nil {
+		c.PrintError(fmt.Sprintf("ERROR : %s\n", err))
+		return 1
+	}
+
+	if *c.flagParentFunc != "" {
+		parentDir := *c.flagParentDir
+		if parentDir == "" {
+			parentDir = *c.flagDir
+		}
+		wired, err := wire(parentDir, *c.flagParentFunc, spec)
+		if err != nil {
+			c.PrintError(fmt.Sprintf("ERROR : %s\n", err))
+			return 1
+		}
+		files = append(files, wired)
+	}
+
+	for _, f := range files {
+		if *c.flagDryRun {
+			c.Print(fmt.Sprintf("==> %s <==\n%s\n", f.path, f.content))
+			continue
+		}
+		if err := writeFile(f); err != nil {
+			c.PrintError(fmt.Sprintf("ERROR : %s\n", err))
+			return 1
+		}
+		c.Print(fmt.Sprintf("wrote %s\n", f.path))
+	}
+	if !*c.flagDryRun && !*c.flagNoTest {
+		c.Print("Run 'go test -update' in the package to create the golden files.\n")
+	}
+	return 0
+}
+
+func (c NewCommand) spec() (*commandSpec, error) {
+	if err := validateName(*c.argName); err != nil {
+		return nil, err
+	}
+	pkg, err := packageName(*c.flagDir)
+	if err != nil {
+		return nil, err
+	}
+	spec := &commandSpec{
+		Name:     *c.argName,
+		Type:     typeName(*c.argName),
+		Package:  pkg,
+		Dir:      *c.flagDir,
+		Short:    *c.flagShort,
+		IsParent: *c.flagKind == "parent",
+		WithTest: !*c.flagNoTest,
+	}
+
+	if spec.IsParent && (len(*c.flagFlags) > 0 || len(*c.flagArgs) > 0) {
+		return nil, fmt.Errorf("parent command cannot have --flag and --arg")
+	}
+	for _, f := range *c.flagFlags {
+		flag, err := parseFlagSpec(f)
+		if err != nil {
+			return nil, err
+		}
+		spec.Flags = append(spec.Flags, flag)
+	}
+	for i, a := range *c.flagArgs {
+		name, usage, _ := strings.Cut(a, ":")
+		if name == "" {
+			return nil, fmt.Errorf("invalid --arg (%s)", a)
+		}
+		spec.Args = append(spec.Args, argSpec{Index: i, Name: name, Field: fieldName(name), Usage: usage})
+	}
+	return spec, nil
+}
+
+func writeFile(f file) error {
+	if !f.overwrite {
+		if _, err := os.Stat(f.path); err == nil {
+			return fmt.Errorf("%s already exists", f.path)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(f.path, f.content, 0644)
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"os"
+
+	"github.com/kmio11/mycmd"
+)
+
+func main() {
+	os.Exit(NewRootCommand().ParseAndExecute(os.Args[1:]))
+}
+
+func NewRootCommand() *mycmd.Root {
+	return mycmd.NewRoot("app").AddCommands(
+		mycmd.NewShell("shell", mycmd.BaseConfig{}),
+	)
+}
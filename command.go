@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"slices"
+
+	"github.com/kmio11/mycmd/wflag"
 )
 
 type (
@@ -39,9 +42,67 @@ type (
 	HiddenSupported interface {
 		Hidden() bool
 	}
+
+	AliasesSupported interface {
+		Aliases() []string
+	}
+
+	InReaderSupported interface {
+		SetInReader(r io.Reader)
+	}
+
+	DeprecatedSupported interface {
+		Deprecated() string
+	}
+
+	HelpTopicsSupported interface {
+		HelpTopics() []HelpTopic
+	}
+
+	FlagSetSupported interface {
+		FS() *wflag.FlagSet
+	}
+
+	PersistentFlagSetSupported interface {
+		PersistentFS() *wflag.FlagSet
+	}
+
+	ResetSupported interface {
+		Reset()
+	}
 )
 
+// findCommand returns the subcommand of p whose name or alias is name, or nil.
+// Hidden commands are also returned.
+func findCommand(p ParentCommand, name string) Command {
+	for _, sub := range p.Commands() {
+		if sub.Name() == name {
+			return sub
+		}
+	}
+	for _, sub := range p.Commands() {
+		if v, ok := sub.(AliasesSupported); ok && slices.Contains(v.Aliases(), name) {
+			return sub
+		}
+	}
+	return nil
+}
+
+// argsExpander is implemented by the command which rewrites the arguments before parsing them.
+type argsExpander interface {
+	expandArgs(args []string) ([]string, error)
+}
+
 func parseCommand(c Command, args []string) (int, error) {
+	if v, ok := c.(argsExpander); ok {
+		expanded, err := v.expandArgs(args)
+		if err != nil {
+			c.PrintError(fmt.Sprintf("ERROR : %s\n", err))
+			return 2, err
+		}
+		args = expanded
+	}
+
 	err := c.Parse(args)
 	if err != nil {
 		if c.IsHelpRequested(err) {
@@ -61,18 +122,82 @@ func parseCommand(c Command, args []string) (int, error) {
 
 // RunCommand parses and executes the command.
 func RunCommand(c Command, args []string) int {
-	ret, err := parseCommand(c, args)
-	if err != nil {
-		return ret
-	}
-	return c.Execute()
+	code, _ := run(context.Background(), c, args, execute)
+	return code
 }
 
 // RunCommandContext parses and executes the command with context.
+// If the tree of the command has Instrumentation, the spans of the invocation are reported to it.
 func RunCommandContext(ctx context.Context, c Command, args []string) int {
-	ret, err := parseCommand(c, args)
-	if err != nil {
-		return ret
+	code, _ := run(ctx, c, args, executeContext)
+	return code
+}
+
+// run parses args with c and executes c by exec, reporting the spans if the tree of c is instrumented.
+// parseErr is the error of parsing other than the request for the help.
+func run(ctx context.Context, c Command, args []string, exec func(context.Context, Command) int) (code int, parseErr error) {
+	t := startTrace(ctx, c)
+	var err error
+	defer func() {
+		if t == nil {
+			return
+		}
+		// the spans end even if the command panics, with the exit code 1 and the error of the panic.
+		if r := recover(); r != nil {
+			t.finish(1, fmt.Errorf("panic: %v", r))
+			panic(r)
+		}
+		t.finish(code, err)
+	}()
+
+	code, parseErr = parseCommand(c, args)
+	if parseErr != nil {
+		if c.IsHelpRequested(parseErr) {
+			parseErr = nil
+		}
+		t.parsed(c, code, parseErr)
+		err = parseErr
+		return code, parseErr
+	}
+	t.parsed(c, code, nil)
+
+	preExecute(c)
+	defer stopProgress(c)
+	code = exec(t.executing(ctx, c), c)
+	err = executionError(c)
+	return code, nil
+}
+
+// executionErrorProvider is implemented by the command which keeps the error of its execution, e.g. FuncCommand.
+type executionErrorProvider interface {
+	executionError() error
+}
+
+// executionError returns the error of the execution of the command which c resolved to, or nil.
+func executionError(c Command) error {
+	if v, ok := parsedLeaf(c).(executionErrorProvider); ok {
+		return v.executionError()
 	}
+	return nil
+}
+
+// preExecuteHook is implemented by the command which does something between parsing and executing.
+type preExecuteHook interface {
+	preExecute()
+}
+
+func preExecute(c Command) {
+	if v, ok := c.(preExecuteHook); ok {
+		v.preExecute()
+	}
+}
+
+// execute calls Execute of c. ctx is used only for the spans.
+func execute(_ context.Context, c Command) int {
+	return c.Execute()
+}
+
+// executeContext calls ExecuteContext of c.
+func executeContext(ctx context.Context, c Command) int {
 	return c.ExecuteContext(ctx)
 }
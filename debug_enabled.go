@@ -0,0 +1,6 @@
+//go:build mycmd_debug
+
+package mycmd
+
+// debugBuild is true in the builds with the mycmd_debug tag, which enables the checks for the developers.
+const debugBuild = true
@@ -0,0 +1,71 @@
+// Package doc generates the documents of the commands, such as markdown files and man pages.
+package doc
+
+import (
+	"strings"
+
+	"github.com/kmio11/mycmd"
+)
+
+// page is a document of a command or a help topic.
+type page struct {
+	fullName         []string
+	shortDescription string
+	usage            string
+	aliases          []string
+	commands         []*page
+	topics           []*page
+	parent           *page
+	isTopic          bool
+}
+
+func (p *page) name() string {
+	return strings.Join(p.fullName, " ")
+}
+
+// baseName returns the name of the file without extension, e.g. "example_mod_edit".
+func (p *page) baseName() string {
+	return strings.Join(p.fullName, "_")
+}
+
+// newPage returns the page of c and its descendants. Hidden commands are skipped.
+func newPage(c mycmd.Command, parent *page) *page {
+	p := &page{
+		fullName:         mycmd.FullName(c),
+		shortDescription: c.ShortDescription(),
+		usage:            strings.Trim(c.Usage(), "\n"),
+		parent:           parent,
+	}
+	if v, ok := c.(mycmd.AliasesSupported); ok {
+		p.aliases = v.Aliases()
+	}
+	if v, ok := c.(mycmd.ParentCommand); ok {
+		for _, sub := range v.Commands() {
+			if h, ok := sub.(mycmd.HiddenSupported); ok && h.Hidden() {
+				continue
+			}
+			p.commands = append(p.commands, newPage(sub, p))
+		}
+	}
+	if v, ok := c.(mycmd.HelpTopicsSupported); ok {
+		for _, topic := range v.HelpTopics() {
+			p.topics = append(p.topics, &page{
+				fullName:         append(mycmd.FullName(c), topic.Name),
+				shortDescription: topic.ShortDescription,
+				usage:            strings.Trim(topic.Long, "\n"),
+				parent:           p,
+				isTopic:          true,
+			})
+		}
+	}
+	return p
+}
+
+// pages returns p and all its descendants in depth-first order.
+func (p *page) pages() []*page {
+	pages := []*page{p}
+	for _, sub := range p.commands {
+		pages = append(pages, sub.pages()...)
+	}
+	return append(pages, p.topics...)
+}
@@ -0,0 +1,110 @@
+package doc
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/kmio11/mycmd"
+)
+
+// ManHeader is the header of the man pages.
+type ManHeader struct {
+	// Section is the section of the pages of the commands. "1" is used if it is empty.
+	// The pages of the help topics are always in the section 7 (miscellaneous).
+	Section string
+	// Date is shown at the center of the footer, e.g. "Jan 2006".
+	Date string
+	// Source is shown at the left of the footer, e.g. the name and the version of the tool.
+	Source string
+	// Manual is shown at the center of the header, e.g. "Example Manual".
+	Manual string
+}
+
+// GenMan writes the man page of c to w.
+func GenMan(c mycmd.Command, header ManHeader, w io.Writer) error {
+	return writeMan(newPage(c, nil), header, w)
+}
+
+// GenManTree writes the man pages of c, its descendants and their help topics to dir.
+// The file of each page is named after the full name of the command and the section, e.g. "example-mod-edit.1".
+func GenManTree(c mycmd.Command, header ManHeader, dir string) ([]string, error) {
+	files := []string{}
+	for _, p := range newPage(c, nil).pages() {
+		filename := filepath.Join(dir, fmt.Sprintf("%s.%s", manName(p), manSection(p, header)))
+		if err := writeFile(filename, func(w io.Writer) error { return writeMan(p, header, w) }); err != nil {
+			return files, err
+		}
+		files = append(files, filename)
+	}
+	return files, nil
+}
+
+func manName(p *page) string {
+	return strings.Join(p.fullName, "-")
+}
+
+func manSection(p *page, header ManHeader) string {
+	if p.isTopic {
+		return "7"
+	}
+	if header.Section == "" {
+		return "1"
+	}
+	return header.Section
+}
+
+func manReference(p *page, header ManHeader) string {
+	return fmt.Sprintf("\\fB%s\\fP(%s)", manEscape(manName(p)), manSection(p, header))
+}
+
+func writeMan(p *page, header ManHeader, w io.Writer) error {
+	ew := &errWriter{w: w}
+	fmt.Fprintf(ew, ".TH %q %q %q %q %q\n",
+		strings.ToUpper(manName(p)), manSection(p, header), header.Date, header.Source, header.Manual,
+	)
+
+	fmt.Fprint(ew, ".SH NAME\n")
+	if p.shortDescription == "" {
+		fmt.Fprintf(ew, "%s\n", manEscape(manName(p)))
+	} else {
+		fmt.Fprintf(ew, "%s \\- %s\n", manEscape(manName(p)), manEscape(p.shortDescription))
+	}
+
+	if p.isTopic {
+		fmt.Fprintf(ew, ".SH DESCRIPTION\n.nf\n%s\n.fi\n", manEscape(p.usage))
+	} else {
+		fmt.Fprintf(ew, ".SH SYNOPSIS\n.nf\n%s\n.fi\n", manEscape(p.usage))
+	}
+
+	if len(p.commands) > 0 {
+		fmt.Fprint(ew, ".SH COMMANDS\n")
+		for _, sub := range p.commands {
+			fmt.Fprintf(ew, ".TP\n%s\n%s\n", manReference(sub, header), manEscape(sub.shortDescription))
+		}
+	}
+	if len(p.topics) > 0 {
+		fmt.Fprint(ew, ".SH ADDITIONAL HELP TOPICS\n")
+		for _, topic := range p.topics {
+			fmt.Fprintf(ew, ".TP\n%s\n%s\n", manReference(topic, header), manEscape(topic.shortDescription))
+		}
+	}
+	if p.parent != nil {
+		fmt.Fprintf(ew, ".SH SEE ALSO\n%s\n", manReference(p.parent, header))
+	}
+	return ew.err
+}
+
+// manEscape escapes the characters which have special meanings in roff.
+func manEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,100 @@
+package doc
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/kmio11/mycmd"
+)
+
+// GenMarkdown writes the markdown document of c to w.
+func GenMarkdown(c mycmd.Command, w io.Writer) error {
+	return writeMarkdown(newPage(c, nil), w)
+}
+
+// GenMarkdownTree writes the markdown documents of c, its descendants and their help topics to dir.
+// The file of each document is named after the full name of the command, e.g. "example_mod_edit.md".
+func GenMarkdownTree(c mycmd.Command, dir string) ([]string, error) {
+	files := []string{}
+	for _, p := range newPage(c, nil).pages() {
+		filename := filepath.Join(dir, p.baseName()+".md")
+		if err := writeFile(filename, func(w io.Writer) error { return writeMarkdown(p, w) }); err != nil {
+			return files, err
+		}
+		files = append(files, filename)
+	}
+	return files, nil
+}
+
+func writeMarkdown(p *page, w io.Writer) error {
+	ew := &errWriter{w: w}
+	fmt.Fprintf(ew, "## %s\n\n", p.name())
+	if p.shortDescription != "" {
+		fmt.Fprintf(ew, "%s\n\n", p.shortDescription)
+	}
+
+	if p.isTopic {
+		fmt.Fprintf(ew, "%s\n\n", p.usage)
+	} else {
+		fmt.Fprintf(ew, "### Synopsis\n\n```\n%s\n```\n\n", p.usage)
+	}
+
+	if len(p.commands) > 0 {
+		fmt.Fprint(ew, "### Commands\n\n")
+		for _, sub := range p.commands {
+			fmt.Fprintf(ew, "* %s\n", markdownLink(sub))
+		}
+		fmt.Fprint(ew, "\n")
+	}
+	if len(p.topics) > 0 {
+		fmt.Fprint(ew, "### Additional help topics\n\n")
+		for _, topic := range p.topics {
+			fmt.Fprintf(ew, "* %s\n", markdownLink(topic))
+		}
+		fmt.Fprint(ew, "\n")
+	}
+	if p.parent != nil {
+		fmt.Fprintf(ew, "### See also\n\n* %s\n", markdownLink(p.parent))
+	}
+	return ew.err
+}
+
+func markdownLink(p *page) string {
+	link := fmt.Sprintf("[%s](%s.md)", p.name(), p.baseName())
+	if p.shortDescription == "" {
+		return link
+	}
+	return fmt.Sprintf("%s - %s", link, p.shortDescription)
+}
+
+func writeFile(filename string, write func(w io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// errWriter keeps the first error of Write so that the error check can be done once at the end.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *errWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.w.Write(p)
+	w.err = err
+	return n, err
+}
@@ -1,19 +1,24 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 
 	fv "github.com/kmio11/flag-validator/pflag-validator"
 	"github.com/kmio11/mycmd"
 )
 
-// BuildCommand is an example command which has flags and arguments.
+// BuildCommand is an example command which has flags and arguments declared by struct tags.
 type BuildCommand struct {
 	*mycmd.Base
 
-	flagOut    *string
-	flagRace   *bool
-	argPackage *string
+	opts buildOptions
+}
+
+type buildOptions struct {
+	Out     string `flag:"out,o" usage:"write the resulting executable to the named output file" required:"true" env:"EXAMPLE_BUILD_OUT"`
+	Race    bool   `flag:"race" usage:"enable data race detection"`
+	Package string `arg:"0,packages" usage:"the packages named by the import paths" required:"true"`
 }
 
 func NewBuildCommand() *BuildCommand {
@@ -23,16 +28,13 @@ func NewBuildCommand() *BuildCommand {
 			mycmd.BaseConfig{
 				ShortDescription: "compile packages and dependencies",
 				ShortUsage:       "--out output [--race] <packages>",
+				Aliases:          []string{"b"},
 			},
 		),
 	}
 
-	// set flags
-	cmd.flagOut = cmd.FS().StringP("out", "o", "", "write the resulting executable to the named output file")
-	cmd.flagRace = cmd.FS().Bool("race", false, "enable data race detection")
-
-	// set arguments
-	cmd.argPackage = cmd.FS().ArgString(0, "packages", "the packages named by the import paths")
+	// set flags and arguments. required ones are prompted when the prompt is enabled.
+	cmd.FS().MustBind(&cmd.opts)
 
 	// set validation rules
 	cmd.FS().SetValidationRules(
@@ -44,9 +46,23 @@ func NewBuildCommand() *BuildCommand {
 }
 
 func (c BuildCommand) Execute() int {
+	return c.ExecuteContext(context.Background())
+}
+
+func (c BuildCommand) ExecuteContext(ctx context.Context) int {
+	steps := []string{"resolving dependencies", "compiling", "linking"}
+	progress := c.StartProgress(ctx, steps[0], int64(len(steps)))
+	for _, step := range steps {
+		progress.SetMessage(step)
+		c.Logger().Debug("build step", "step", step)
+		progress.Add(1)
+	}
+	progress.Stop()
+	c.Logger().Info("build finished", "package", c.opts.Package, "out", c.opts.Out)
+
 	c.Print(fmt.Sprintf(
 		"Build successful. package=<%s> out=<%s>\n",
-		*c.argPackage, *c.flagOut,
+		c.opts.Package, c.opts.Out,
 	))
 	return 0
 }
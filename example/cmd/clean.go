@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	fv "github.com/kmio11/flag-validator/pflag-validator"
+	"github.com/kmio11/mycmd"
+	"github.com/kmio11/mycmd/wflag"
+)
+
+// NewCleanCommand returns an example command built without embedding Base.
+func NewCleanCommand() *mycmd.FuncCommand {
+	var cache, dryRun *bool
+
+	return mycmd.MustNew("clean",
+		mycmd.WithShort("remove object files and cached files"),
+		mycmd.WithUsage("[--cache] [-n]"),
+		mycmd.WithFlags(func(fs *wflag.FlagSet) {
+			cache = fs.Bool("cache", false, "remove the entire build cache")
+			dryRun = fs.BoolP("dry-run", "n", false, "print the remove commands but do not run them")
+			fs.SetValidationRules(
+				fv.NumberOfArgs(0),
+			)
+		}),
+		mycmd.WithRun(func(ctx context.Context, cmd *mycmd.FuncCommand) error {
+			// the removal is not started if it is already cancelled.
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			target := "object files"
+			if *cache {
+				target = "build cache"
+			}
+			if *dryRun {
+				cmd.Print(fmt.Sprintf("would remove %s\n", target))
+				return nil
+			}
+			cmd.Print(fmt.Sprintf("removed %s\n", target))
+			return nil
+		}),
+	)
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	fv "github.com/kmio11/flag-validator/pflag-validator"
+	"github.com/kmio11/mycmd"
+	"github.com/kmio11/mycmd/doc"
+)
+
+// DocsCommand is an example hidden command which generates the documents of all commands.
+type DocsCommand struct {
+	*mycmd.Base
+
+	flagFormat *string
+	flagDir    *string
+}
+
+func NewDocsCommand() *DocsCommand {
+	cmd := &DocsCommand{
+		Base: mycmd.NewBase(
+			"docs",
+			mycmd.BaseConfig{
+				ShortDescription: "generate the documents of the commands",
+				ShortUsage:       "[--format markdown|man] --dir dir",
+				Hidden:           true,
+			},
+		),
+	}
+
+	// set flags
+	cmd.flagFormat = cmd.FS().Enum("format", "markdown", []string{"markdown", "man"}, "format of the documents (markdown|man)")
+	cmd.flagDir = cmd.FS().String("dir", "", "directory where the documents are written")
+
+	// set validation rules
+	cmd.FS().SetValidationRules(
+		fv.NumberOfArgs(0),
+		fv.Flag("dir").Required(),
+	)
+
+	return cmd
+}
+
+func (c DocsCommand) Execute() int {
+	return c.ExecuteContext(context.Background())
+}
+
+func (c DocsCommand) ExecuteContext(ctx context.Context) int {
+	// the documents of all commands are generated from the root.
+	var root mycmd.Command = c
+	for {
+		sub, ok := root.(mycmd.SubCommand)
+		if !ok || sub.Parent() == nil {
+			break
+		}
+		root = sub.Parent()
+	}
+
+	var (
+		files []string
+		err   error
+	)
+	switch *c.flagFormat {
+	case "man":
+		files, err = doc.GenManTree(root, doc.ManHeader{Source: "example", Manual: "Example Manual"}, *c.flagDir)
+	default:
+		files, err = doc.GenMarkdownTree(root, *c.flagDir)
+	}
+	for _, f := range files {
+		c.Print(fmt.Sprintf("wrote %s\n", f))
+	}
+	if err != nil {
+		c.PrintError(fmt.Sprintf("ERROR : %s\n", err))
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	fv "github.com/kmio11/flag-validator/pflag-validator"
+	"github.com/kmio11/mycmd"
+	"github.com/kmio11/mycmd/wflag"
+)
+
+// NewInstallCommand returns an example of the deprecated command, which is kept for compatibility.
+func NewInstallCommand() *mycmd.FuncCommand {
+	var pkg *string
+
+	return mycmd.MustNew("install",
+		mycmd.WithShort("compile and install packages and dependencies"),
+		mycmd.WithUsage("<packages>"),
+		mycmd.WithDeprecated("use 'example build' instead"),
+		mycmd.WithFlags(func(fs *wflag.FlagSet) {
+			pkg = fs.ArgString(0, "packages", "the packages named by the import paths")
+			fs.SetValidationRules(
+				fv.NumberOfArgs(1),
+			)
+		}),
+		mycmd.WithRun(func(ctx context.Context, cmd *mycmd.FuncCommand) error {
+			cmd.Print(fmt.Sprintf("installed %s\n", *pkg))
+			return nil
+		}),
+	)
+}
@@ -1,10 +1,13 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
 
 	fv "github.com/kmio11/flag-validator/pflag-validator"
 	"github.com/kmio11/mycmd"
+	"github.com/spf13/pflag"
 )
 
 type (
@@ -20,6 +23,7 @@ type (
 		flagFmt   *bool
 		flagPrint *bool
 		flagJSON  *bool
+		argFile   *string
 	}
 )
 
@@ -44,7 +48,7 @@ func NewEditCommand() *EditCommand {
 			"edit",
 			mycmd.BaseConfig{
 				ShortDescription: "edit a file from tools or scripts",
-				ShortUsage:       "[-fmt|-print|-json]",
+				ShortUsage:       "[-fmt|-print|-json] [go.mod]",
 			},
 		),
 	}
@@ -54,9 +58,17 @@ func NewEditCommand() *EditCommand {
 	cmd.flagPrint = cmd.FS().Bool("print", false, "prints the file in its text format")
 	cmd.flagJSON = cmd.FS().Bool("json", false, "prints the file in JSON format")
 
+	// set arguments
+	cmd.argFile = cmd.FS().ArgString(0, "go.mod", "the file to edit, or - to read the standard input")
+
 	// set validation rules
 	cmd.FS().SetValidationRules(
-		fv.NumberOfArgs(0),
+		fv.RuleFunc(func(fs *pflag.FlagSet) error {
+			if fs.NArg() > 1 {
+				return fmt.Errorf("this command needs at most 1 argument but specified %d arguments", fs.NArg())
+			}
+			return nil
+		}),
 		fv.MutuallyExclusive(
 			fv.Flag("fmt"),
 			fv.Flag("print"),
@@ -68,11 +80,18 @@ func NewEditCommand() *EditCommand {
 }
 
 func (c EditCommand) Execute() int {
+	return c.ExecuteContext(context.Background())
+}
+
+func (c EditCommand) ExecuteContext(ctx context.Context) int {
 	if *c.flagFmt {
 		c.Print(fmt.Sprintln("formatted!!"))
 		return 0
 	}
 	if *c.flagPrint {
+		if *c.argFile != "" {
+			return c.printFile(*c.argFile)
+		}
 		c.Print(fmt.Sprintln("printed in text format!!"))
 		return 0
 	}
@@ -84,3 +103,20 @@ func (c EditCommand) Execute() int {
 	c.Print(fmt.Sprintln("edited!!"))
 	return 0
 }
+
+func (c EditCommand) printFile(name string) int {
+	f, err := c.OpenInput(name)
+	if err != nil {
+		c.PrintError(fmt.Sprintf("ERROR : %s\n", err))
+		return 1
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		c.PrintError(fmt.Sprintf("ERROR : %s\n", err))
+		return 1
+	}
+	c.Print(string(data))
+	return 0
+}
@@ -0,0 +1,16 @@
+package cmd
+
+import "github.com/kmio11/mycmd"
+
+// EnvironmentTopic is an example help topic which describes the environment variables.
+var EnvironmentTopic = mycmd.HelpTopic{
+	Name:             "environment",
+	ShortDescription: "environment variables",
+	Long: `
+The example command consults environment variables for configuration.
+A flag specified on the command line takes precedence over the environment variable.
+
+	EXAMPLE_BUILD_OUT
+		the output file of 'example build', the same as --out.
+`,
+}
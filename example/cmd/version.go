@@ -1,15 +1,25 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
 
 	fv "github.com/kmio11/flag-validator/pflag-validator"
 	"github.com/kmio11/mycmd"
+	"github.com/kmio11/mycmd/output"
 )
 
-// VersionCommand is an example command which has neither flags or arguments.
+// VersionCommand is an example command which has no arguments and prints structured output.
 type VersionCommand struct {
 	*mycmd.Base
+
+	output *output.Options
+}
+
+type versionInfo struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
 }
 
 func NewVersionCmd() *VersionCommand {
@@ -18,11 +28,14 @@ func NewVersionCmd() *VersionCommand {
 			"version",
 			mycmd.BaseConfig{
 				ShortDescription: "print version",
-				ShortUsage:       "",
+				ShortUsage:       "[-o json|yaml|table|template=<template>]",
 			},
 		),
 	}
 
+	// set flags
+	cmd.output = output.AddFlags(cmd.FS(), output.Config{})
+
 	// set validation rules
 	cmd.FS().SetValidationRules(
 		fv.NumberOfArgs(0),
@@ -32,6 +45,21 @@ func NewVersionCmd() *VersionCommand {
 }
 
 func (c VersionCommand) Execute() int {
-	c.Print(fmt.Sprintln("v1.0.0"))
+	return c.ExecuteContext(context.Background())
+}
+
+func (c VersionCommand) ExecuteContext(ctx context.Context) int {
+	info := versionInfo{
+		Version: "v1.0.0",
+		Commit:  "0123abc",
+	}
+	err := c.output.Print(c.OutWriter(), info, func(w io.Writer) error {
+		_, err := fmt.Fprintln(w, info.Version)
+		return err
+	})
+	if err != nil {
+		c.PrintError(fmt.Sprintf("ERROR : %s\n", err))
+		return 1
+	}
 	return 0
 }
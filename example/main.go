@@ -17,5 +17,14 @@ func NewRootCommand() *mycmd.Root {
 		cmd.NewVersionCmd(),
 		cmd.NewBuildCommand(),
 		cmd.NewModCommand(),
-	)
+		cmd.NewCleanCommand(),
+		mycmd.NewShell("shell", mycmd.BaseConfig{
+			ShortDescription: "start an interactive shell",
+		}),
+		cmd.NewDocsCommand(),
+		cmd.NewInstallCommand(),
+	).AddHelpTopics(
+		cmd.EnvironmentTopic,
+	).EnablePrompt().EnableLogging().EnableResponseFiles().EnableDebugFlags().
+		EnableServeStdio(NewRootCommand)
 }
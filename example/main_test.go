@@ -1,65 +1,479 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
+	fv "github.com/kmio11/flag-validator/pflag-validator"
 	"github.com/kmio11/mycmd"
+	"github.com/kmio11/mycmd/example/cmd"
 	"github.com/kmio11/mycmd/testutils"
+	"github.com/kmio11/mycmd/wflag"
+	"github.com/stretchr/testify/assert"
 )
 
+var rootTests = []testutils.TestCaseRootParseAndExecute{
+	{
+		Name: "version",
+		Args: []string{
+			"version",
+		},
+		Want: 0,
+	},
+	{
+		Name: "version_json",
+		Args: []string{
+			"version", "-o", "json",
+		},
+		Want: 0,
+	},
+	{
+		Name: "version_yaml",
+		Args: []string{
+			"version", "--output", "yaml",
+		},
+		Want: 0,
+	},
+	{
+		Name: "version_table",
+		Args: []string{
+			"version", "-o", "table", "--columns", "version",
+		},
+		Want: 0,
+	},
+	{
+		Name: "version_template",
+		Args: []string{
+			"version", "-o", "template={{.Version}} ({{.Commit}})\n",
+		},
+		Want: 0,
+	},
+	{
+		Name: "version_unknown_format",
+		Args: []string{
+			"version", "-o", "xml",
+		},
+		Want: 2,
+	},
+	{
+		Name: "help_version",
+		Args: []string{
+			"help", "version",
+		},
+		Want: 0,
+	},
+	{
+		Name: "build",
+		Args: []string{
+			"build", "--out", "output", "--race", "packages",
+		},
+		Want: 0,
+	},
+	{
+		Name: "build_out_from_env",
+		Args: []string{
+			"build", "packages",
+		},
+		Want: 0,
+		Setup: func(t *testing.T, tt testutils.TestCaseRootParseAndExecute) {
+			t.Setenv("EXAMPLE_BUILD_OUT", "output_from_env")
+		},
+	},
+	{
+		Name: "build_verbose",
+		Args: []string{
+			"-vv", "build", "--out", "output", "packages",
+		},
+		Want: 0,
+	},
+	{
+		Name: "build_quiet",
+		Args: []string{
+			"build", "--out", "output", "-q", "packages",
+		},
+		Want: 0,
+	},
+	{
+		Name: "build_invalid_log_format",
+		Args: []string{
+			"--log-format", "xml", "build", "--out", "output", "packages",
+		},
+		Want: 2,
+	},
+	{
+		Name: "help_build",
+		Args: []string{
+			"help", "build",
+		},
+		Want: 0,
+	},
+	{
+		Name: "build_response_file",
+		Args: []string{
+			"build", "@testdata/response/build.rsp",
+		},
+		Want: 0,
+	},
+	{
+		Name: "build_response_file_cycle",
+		Args: []string{
+			"build", "@testdata/response/cycle.rsp",
+		},
+		Want: 2,
+	},
+	{
+		Name: "build_response_file_indirect_cycle",
+		Args: []string{
+			"build", "@testdata/response/race.rsp", "@testdata/response/cycle-a.rsp",
+		},
+		Want: 2,
+	},
+	{
+		Name: "build_response_file_not_found",
+		Args: []string{
+			"build", "@testdata/response/notfound.rsp",
+		},
+		Want: 2,
+	},
+	{
+		Name: "clean",
+		Args: []string{
+			"clean", "--cache", "-n",
+		},
+		Want: 0,
+	},
+	{
+		Name: "help_clean",
+		Args: []string{
+			"help", "clean",
+		},
+		Want: 0,
+	},
+	{
+		Name: "mod_edit",
+		Args: []string{
+			"mod", "edit", "--fmt",
+		},
+		Want: 0,
+	},
+	{
+		Name: "mod_edit_print_stdin",
+		Args: []string{
+			"mod", "edit", "--print", "-",
+		},
+		Stdin: "module example.com/hello\n\ngo 1.21\n",
+		Want:  0,
+	},
+	{
+		Name: "mod_edit_print_file_not_found",
+		Args: []string{
+			"mod", "edit", "--print", "testdata/notfound.mod",
+		},
+		Want: 1,
+	},
+	{
+		Name: "help_mod",
+		Args: []string{
+			"help", "mod",
+		},
+		Want: 0,
+	},
+	{
+		Name: "help_mod_edit",
+		Args: []string{
+			"help", "mod", "edit",
+		},
+		Want: 0,
+	},
+	{
+		Name: "help_mod_unknown",
+		Args: []string{
+			"help", "mod", "unknown",
+		},
+		Want: 2,
+	},
+	{
+		Name: "help_build_extra",
+		Args: []string{
+			"help", "build", "extra",
+		},
+		Want: 2,
+	},
+	{
+		Name: "help_alias",
+		Args: []string{
+			"help", "b",
+		},
+		Want: 0,
+	},
+	{
+		Name: "build_alias",
+		Args: []string{
+			"b", "-o", "out", "pkg",
+		},
+		Want: 0,
+	},
+	{
+		Name: "install_deprecated",
+		Args: []string{
+			"install", "pkg",
+		},
+		Want: 0,
+	},
+	{
+		Name: "help_flag",
+		Args: []string{
+			"--help",
+		},
+		Want: 0,
+	},
+	{
+		Name: "mod_help_flag",
+		Args: []string{
+			"mod", "--help",
+		},
+		Want: 0,
+	},
+	{
+		Name: "mod_edit_help_flag",
+		Args: []string{
+			"mod", "edit", "-h",
+		},
+		Want: 0,
+	},
+	{
+		Name: "help_all",
+		Args: []string{
+			"help", "--all",
+		},
+		Want: 0,
+	},
+	{
+		Name: "help_all_flags_depth",
+		Args: []string{
+			"help", "-a", "--flags", "--depth", "1",
+		},
+		Want: 0,
+	},
+	{
+		Name: "help_all_mod",
+		Args: []string{
+			"help", "-a", "mod",
+		},
+		Want: 0,
+	},
+	{
+		Name: "help_tree",
+		Args: []string{
+			"help", "tree",
+		},
+		Want: 0,
+	},
+	{
+		Name: "help_mod_tree_flags",
+		Args: []string{
+			"help", "--flags", "mod", "tree",
+		},
+		Want: 0,
+	},
+	{
+		Name: "help_help",
+		Args: []string{
+			"help", "help",
+		},
+		Want: 0,
+	},
+	{
+		Name: "help_unknown_flag",
+		Args: []string{
+			"help", "--bogus",
+		},
+		Want: 2,
+	},
+	{
+		Name: "help_all_hidden",
+		Args: []string{
+			"help", "--all", "--hidden",
+		},
+		Want: 0,
+	},
+	{
+		Name: "help_environment",
+		Args: []string{
+			"help", "environment",
+		},
+		Want: 0,
+	},
+	{
+		Name: "environment_is_not_command",
+		Args: []string{
+			"environment",
+		},
+		Want: 2,
+	},
+	{
+		Name: "mod_help_edit",
+		Args: []string{
+			"mod", "help", "edit",
+		},
+		Want: 0,
+	},
+}
+
 func TestRoot_ParseAndExecute(t *testing.T) {
+	testdata := testutils.NewTestData(t, t.Name()).WithNormalizers(testutils.NormalizeVersion())
+	testdata.CheckOrphanGoldens(t)
+
+	testutils.RunTestRoot_ParseAndExecute(
+		t, rootTests, testdata,
+		func() mycmd.Command {
+			return NewRootCommand()
+		},
+		nil,
+	)
+}
+
+func TestRoot_ParseAndExecuteContext(t *testing.T) {
 	testdata := testutils.NewTestData(t, t.Name())
-	tests := []testutils.TestCaseRootParseAndExecute{
+	testdata.CheckOrphanGoldens(t)
+	tests := []testutils.TestCaseParentParseAndExecute{
 		{
-			Name: "version",
-			Args: []string{
-				"version",
-			},
-			Want: 0,
+			Name:     "version",
+			Args:     []string{"version"},
+			Parallel: true,
+			Want:     0,
+		},
+		{
+			Name:     "mod_edit",
+			Args:     []string{"mod", "edit", "--fmt"},
+			Parallel: true,
+			Want:     0,
 		},
 		{
-			Name: "help_version",
-			Args: []string{
-				"help", "version",
-			},
+			Name: "clean",
+			Args: []string{"clean"},
 			Want: 0,
 		},
 		{
-			Name: "build",
-			Args: []string{
-				"build", "--out", "output", "--race", "packages",
-			},
+			Name:      "clean_cancelled",
+			Args:      []string{"clean"},
+			Cancelled: true,
+			Want:      1,
+		},
+		{
+			Name:    "clean_deadline_exceeded",
+			Args:    []string{"clean"},
+			Timeout: -1,
+			Want:    1,
+		},
+		{
+			Name:      "shell_cancelled",
+			Args:      []string{"shell"},
+			Stdin:     "version\n",
+			Cancelled: true,
+			Want:      0,
+		},
+		{
+			Name: "build_env",
+			Args: []string{"build", "pkg"},
+			Env:  map[string]string{"EXAMPLE_BUILD_OUT": "bin/app"},
 			Want: 0,
 		},
 		{
-			Name: "help_build",
-			Args: []string{
-				"help", "build",
-			},
+			Name: "build_debug_flags",
+			Args: []string{"-q", "build", "--debug-flags", "pkg"},
+			Env:  map[string]string{"EXAMPLE_BUILD_OUT": "bin/app"},
 			Want: 0,
 		},
 		{
-			Name: "mod_edit",
-			Args: []string{
-				"mod", "edit", "--fmt",
-			},
+			Name: "build_dir",
+			Args: []string{"build", "@build.rsp"},
+			Dir:  filepath.Join("testdata", "response"),
 			Want: 0,
 		},
+	}
+
+	testutils.RunTestParent_ParseAndExecuteContext(
+		t, tests, testdata,
+		func() mycmd.Command {
+			return NewRootCommand()
+		},
+		nil,
+	)
+}
+
+func TestDocs(t *testing.T) {
+	testdata := testutils.NewTestData(t, t.Name())
+	testdata.CheckOrphanGoldens(t)
+	outDir := func(name string) string {
+		return testdata.FileName(t, ".tmp_"+name)
+	}
+	tests := []testutils.TestCaseRootParseAndExecute{
 		{
-			Name: "help_mod",
-			Args: []string{
-				"help", "mod",
-			},
+			Name: "markdown",
+			Args: []string{"docs", "--dir", outDir("markdown")},
 			Want: 0,
 		},
 		{
-			Name: "mod_help_edit",
-			Args: []string{
-				"mod", "help", "edit",
-			},
+			Name: "man",
+			Args: []string{"docs", "--format", "man", "--dir", outDir("man")},
 			Want: 0,
 		},
 	}
+	for i := range tests {
+		tests[i].Setup = func(t *testing.T, tt testutils.TestCaseRootParseAndExecute) {
+			testdata.TempDirInTestdata(t, "_"+tt.Name)
+		}
+	}
+
+	testutils.RunTestRoot_ParseAndExecute(
+		t, tests, testdata,
+		func() mycmd.Command {
+			return NewRootCommand()
+		},
+		func(t *testing.T, tt testutils.TestCaseRootParseAndExecute, update bool, cmd mycmd.Command, actual []any) {
+			entries, err := os.ReadDir(outDir(tt.Name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range entries {
+				testdata.CompareWithGolden(t, update,
+					testdata.FileName(t, "golden", tt.Name, "docs", e.Name()),
+					testdata.ReadFile(t, filepath.Join(outDir(tt.Name), e.Name())),
+				)
+			}
+		},
+	)
+}
+
+func TestShell(t *testing.T) {
+	testdata := testutils.NewTestData(t, t.Name())
+	testdata.CheckOrphanGoldens(t)
+	tests := []testutils.TestCaseRootParseAndExecute{
+		{
+			Name:  "run_commands",
+			Args:  []string{"shell"},
+			Stdin: "version\nmod edit --fmt\nmod edit --json\nbuild -o 'my output' pkg\nhistory\nexit\n",
+			Want:  0,
+		},
+		{
+			Name:  "parse_error",
+			Args:  []string{"shell"},
+			Stdin: "mod edit --fmt --json\nbuild \"unterminated\n",
+			Want:  2,
+		},
+		{
+			Name:  "exit_code",
+			Args:  []string{"shell"},
+			Stdin: "version\nexit 3\nversion\n",
+			Want:  3,
+		},
+	}
 
 	testutils.RunTestRoot_ParseAndExecute(
 		t, tests, testdata,
@@ -69,3 +483,168 @@ func TestRoot_ParseAndExecute(t *testing.T) {
 		nil,
 	)
 }
+
+func TestHelpSnapshots(t *testing.T) {
+	testdata := testutils.NewTestData(t, t.Name())
+	testdata.CheckOrphanGoldens(t)
+	testutils.RunTestHelpSnapshots(t, testdata, func() mycmd.Command {
+		return NewRootCommand()
+	})
+}
+
+func TestCompatibility(t *testing.T) {
+	testdata := testutils.NewTestData(t, t.Name())
+	testdata.CheckOrphanGoldens(t)
+	testutils.CheckCompatibility(t, testdata, func() mycmd.Command {
+		return NewRootCommand()
+	})
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, mycmd.Validate(NewRootCommand()))
+
+	run := mycmd.WithRun(func(ctx context.Context, cmd *mycmd.FuncCommand) error {
+		return nil
+	})
+	version := cmd.NewVersionCmd()
+	invalid := mycmd.NewRoot("invalid").AddCommands(
+		cmd.NewBuildCommand(),
+		version,
+		mycmd.MustNew("b", run),
+		mycmd.MustNew("help", run),
+		mycmd.MustNew("verify", run, mycmd.WithFlags(func(fs *wflag.FlagSet) {
+			fs.BoolP("vet", "v", false, "run go vet")
+			fs.SetValidationRules(
+				fv.Flag("race").Required(),
+				fv.When(fv.Flag("vet").IsSet())(
+					fv.ValueOf("count").TypeInt().Min(1),
+				),
+			)
+		})),
+		cmd.NewCleanCommand(),
+		version,
+	).AddHelpTopics(
+		mycmd.HelpTopic{Name: "clean"},
+	).EnableLogging()
+
+	err := mycmd.Validate(invalid)
+	assert.Equal(t, strings.Join([]string{
+		`invalid: "b" is used by both build and b`,
+		`invalid: "help" of help is shadowed by the built-in help command`,
+		`invalid: version is added more than once`,
+		`invalid: help topic "clean" is shadowed by clean`,
+		`invalid verify: shorthand -v of flag --vet conflicts with the inherited flag --verbose`,
+		`invalid verify: validation rule 1 refers to undefined flag --race`,
+		`invalid verify: validation rule 2 panics: the flag count is not defined`,
+	}, "\n"), err.Error())
+}
+
+func TestRoot_ParseAndExecute_repeatedly(t *testing.T) {
+	root := NewRootCommand()
+	outWriter, errWriter := new(bytes.Buffer), new(bytes.Buffer)
+	root.SetOutWriter(outWriter)
+	root.SetErrWriter(errWriter)
+	root.SetInReader(strings.NewReader(""))
+
+	assert.Equal(t, 0, root.ParseAndExecute([]string{"build", "-o", "out", "--race", "pkg"}))
+	assert.Contains(t, outWriter.String(), "package=<pkg> out=<out>")
+
+	// the flags and the arguments of the previous invocation are not left.
+	outWriter.Reset()
+	errWriter.Reset()
+	inv := root.Invoke(context.Background(), []string{"build"})
+	assert.Equal(t, 2, inv.ExitCode)
+	assert.Equal(t, []string{"example", "build"}, inv.CommandPath)
+	assert.EqualError(t, inv.ParseError, "The flag [--out] is required")
+
+	inv = root.Invoke(context.Background(), []string{"mod", "--help"})
+	assert.Equal(t, 0, inv.ExitCode)
+	assert.Equal(t, []string{"example", "help"}, inv.CommandPath)
+	assert.NoError(t, inv.ParseError)
+
+	// the history of the previous shell session is not left.
+	root.SetInReader(strings.NewReader("version\nhistory\n"))
+	assert.Equal(t, 0, root.ParseAndExecute([]string{"shell"}))
+	outWriter.Reset()
+	root.SetInReader(strings.NewReader("history\n"))
+	assert.Equal(t, 0, root.ParseAndExecute([]string{"shell"}))
+	assert.Equal(t, "    1  history\n", outWriter.String())
+}
+
+func TestRoot_ParseAndExecute_repeatedlyWithPersistentFlags(t *testing.T) {
+	root := NewRootCommand()
+	outWriter, errWriter := new(bytes.Buffer), new(bytes.Buffer)
+	root.SetOutWriter(outWriter)
+	root.SetErrWriter(errWriter)
+	root.SetInReader(strings.NewReader(""))
+
+	assert.Equal(t, 0, root.ParseAndExecute([]string{"--debug-flags", "version"}))
+	assert.Contains(t, errWriter.String(), "--debug-flags")
+
+	// the persistent flags of the previous invocation are not left.
+	errWriter.Reset()
+	assert.Equal(t, 0, root.ParseAndExecute([]string{"version"}))
+	assert.Empty(t, errWriter.String())
+
+	// nor the ones of the previous line of the shell.
+	errWriter.Reset()
+	root.SetInReader(strings.NewReader("--debug-flags version\nversion\n"))
+	assert.Equal(t, 0, root.ParseAndExecute([]string{"shell"}))
+	assert.Equal(t, 1, strings.Count(errWriter.String(), "--debug-flags"))
+}
+
+func TestInvoker(t *testing.T) {
+	argsList := [][]string{
+		{"version", "-o", "json"},
+		{"build", "-o", "out", "--race", "pkg"},
+		{"build", "pkg"},
+		{"mod", "edit", "--json"},
+		{"help", "mod", "edit"},
+		{"clean", "-n", "--cache"},
+		{"unknown"},
+	}
+
+	// the outputs of the concurrent invocations equal to the ones of the invocations on the fresh trees.
+	type result struct {
+		code           int
+		stdout, stderr string
+	}
+	want := make([]result, len(argsList))
+	for i, args := range argsList {
+		root := NewRootCommand()
+		outWriter, errWriter := new(bytes.Buffer), new(bytes.Buffer)
+		root.SetOutWriter(outWriter)
+		root.SetErrWriter(errWriter)
+		root.SetInReader(strings.NewReader(""))
+		want[i] = result{root.ParseAndExecute(args), outWriter.String(), errWriter.String()}
+	}
+
+	invoker := mycmd.NewInvoker(NewRootCommand)
+	var wg sync.WaitGroup
+	for n := 0; n < 10; n++ {
+		for i, args := range argsList {
+			wg.Add(1)
+			go func(i int, args []string) {
+				defer wg.Done()
+				outWriter, errWriter := new(bytes.Buffer), new(bytes.Buffer)
+				inv := invoker.Invoke(context.Background(), args, strings.NewReader(""), outWriter, errWriter)
+				assert.Equal(t, want[i], result{inv.ExitCode, outWriter.String(), errWriter.String()}, "args %q", args)
+			}(i, args)
+		}
+	}
+	wg.Wait()
+}
+
+func TestScripts(t *testing.T) {
+	testutils.RunTestScripts(t, filepath.Join("testdata", "scripts"), func() mycmd.Command {
+		return NewRootCommand()
+	})
+}
+
+func FuzzRoot(f *testing.F) {
+	testutils.FuzzCommand(f, func() mycmd.Command {
+		return NewRootCommand()
+	}, testutils.FuzzOptions{
+		Seeds: testutils.ArgsOf(rootTests),
+	})
+}
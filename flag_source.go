@@ -0,0 +1,66 @@
+package mycmd
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/kmio11/mycmd/wflag"
+	"github.com/spf13/pflag"
+)
+
+// FlagSource returns the source of the value of the named flag of c after parsing.
+// The flags inherited from the parents are looked up in the parents which parsed them.
+func FlagSource(c Command, name string) wflag.Source {
+	for p := c; p != nil; {
+		if v, ok := p.(FlagSetSupported); ok {
+			if src := v.FS().Source(name); src.Kind != wflag.SourceDefault {
+				return src
+			}
+		}
+		sub, ok := p.(SubCommand)
+		if !ok {
+			break
+		}
+		p = sub.Parent()
+	}
+	return wflag.Source{}
+}
+
+// parsedLeaf returns the command parsed last in the tree of c, which is executed.
+func parsedLeaf(c Command) Command {
+	for {
+		v, ok := c.(interface{ parsed() Command })
+		if !ok || v.parsed() == nil {
+			return c
+		}
+		c = v.parsed()
+	}
+}
+
+// flagSources returns the table of the effective values and the sources of the flags and the arguments of c.
+func flagSources(c Command) string {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tVALUE\tSOURCE")
+	if v, ok := c.(FlagSetSupported); ok {
+		printFlag := func(f *pflag.Flag) {
+			fmt.Fprintf(tw, "--%s\t%q\t%s\n", f.Name, f.Value, FlagSource(c, f.Name))
+		}
+		v.FS().VisitAll(printFlag)
+		var parent Command
+		if sub, ok := c.(SubCommand); ok {
+			parent = sub.Parent()
+		}
+		inheritedFlags(parent).VisitAll(func(f *pflag.Flag) {
+			if v.FS().Lookup(f.Name) == nil {
+				printFlag(f)
+			}
+		})
+		for _, a := range v.FS().Arguments() {
+			fmt.Fprintf(tw, "<%s>\t%q\t%s\n", a.Name, *a.Value, v.FS().ArgSource(a.Index))
+		}
+	}
+	tw.Flush()
+	return buf.String()
+}
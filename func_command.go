@@ -0,0 +1,179 @@
+package mycmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kmio11/mycmd/wflag"
+)
+
+var _ SubCommand = (*FuncCommand)(nil)
+
+// RunFunc is the main processing of FuncCommand.
+// Returning an error makes the exit code 1, or ExitError.Code if it is ExitError.
+type RunFunc func(ctx context.Context, cmd *FuncCommand) error
+
+// FuncCommand is a command built by New, without defining a struct embedding Base.
+type FuncCommand struct {
+	*Base
+	run RunFunc
+	cfg BaseConfig
+	err error
+	// runErr is the error returned by run in the last execution.
+	runErr error
+}
+
+// Option configures the command built by New.
+type Option func(c *FuncCommand)
+
+// WithShort sets the short description.
+func WithShort(description string) Option {
+	return func(c *FuncCommand) {
+		c.cfg.ShortDescription = description
+	}
+}
+
+// WithUsage sets the short usage shown after the command name.
+func WithUsage(usage string) Option {
+	return func(c *FuncCommand) {
+		c.cfg.ShortUsage = usage
+	}
+}
+
+// WithHidden hides the command from Usage of its parent.
+func WithHidden() Option {
+	return func(c *FuncCommand) {
+		c.cfg.Hidden = true
+	}
+}
+
+// WithAliases sets the alternative names of the command.
+func WithAliases(aliases ...string) Option {
+	return func(c *FuncCommand) {
+		c.cfg.Aliases = aliases
+	}
+}
+
+// WithDeprecated marks the command deprecated. The message is shown when the command is used.
+func WithDeprecated(message string) Option {
+	return func(c *FuncCommand) {
+		c.cfg.Deprecated = message
+	}
+}
+
+// WithFlags defines the flags, arguments and validation rules.
+func WithFlags(f func(fs *wflag.FlagSet)) Option {
+	return func(c *FuncCommand) {
+		f(c.FS())
+	}
+}
+
+// WithOptions defines the flags and arguments from the struct tags of opts. See wflag.FlagSet.Bind.
+func WithOptions(opts any) Option {
+	return func(c *FuncCommand) {
+		if err := c.FS().Bind(opts); err != nil {
+			c.err = errors.Join(c.err, err)
+		}
+	}
+}
+
+// WithRun sets the main processing of the command. It is required.
+func WithRun(run RunFunc) Option {
+	return func(c *FuncCommand) {
+		c.run = run
+	}
+}
+
+// New returns a command configured by the options.
+// An error is returned if the options are invalid, e.g. WithRun is missing.
+func New(name string, opts ...Option) (*FuncCommand, error) {
+	if name == "" {
+		return nil, fmt.Errorf("name of the command is empty")
+	}
+	c := &FuncCommand{
+		Base: NewBase(name, BaseConfig{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.run == nil {
+		c.err = errors.Join(c.err, fmt.Errorf("%s: run function is not specified", name))
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	c.shortDescription = c.cfg.ShortDescription
+	c.shortUsage = c.cfg.ShortUsage
+	c.hidden = c.cfg.Hidden
+	c.aliases = c.cfg.Aliases
+	c.deprecated = c.cfg.Deprecated
+	return c, nil
+}
+
+// MustNew is like New but panics if an error occurs.
+func MustNew(name string, opts ...Option) *FuncCommand {
+	c, err := New(name, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Execute executes the main processing of the command.
+func (c *FuncCommand) Execute() int {
+	return c.ExecuteContext(context.Background())
+}
+
+// ExecuteContext is the same as Execute() but accept a context as an argument.
+func (c *FuncCommand) ExecuteContext(ctx context.Context) int {
+	err := c.run(ctx, c)
+	c.runErr = err
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		if exitErr.Err != nil {
+			c.PrintError(fmt.Sprintf("ERROR : %s\n", exitErr.Err))
+		}
+		return exitErr.Code
+	}
+	c.PrintError(fmt.Sprintf("ERROR : %s\n", err))
+	return 1
+}
+
+// Reset forgets the error of the previous execution as well as the flags.
+func (c *FuncCommand) Reset() {
+	c.Base.Reset()
+	c.runErr = nil
+}
+
+func (c *FuncCommand) executionError() error {
+	return c.runErr
+}
+
+// ExitError is an error which specifies the exit code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+// Exit returns an error which makes the command exit with the code.
+// err may be nil not to print any message.
+func Exit(code int, err error) error {
+	return &ExitError{Code: code, Err: err}
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,205 @@
+// Package gateway exposes the commands of a Root over HTTP.
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/kmio11/mycmd"
+	"github.com/kmio11/mycmd/internal/syncbuf"
+)
+
+// ContentTypeNDJSON is the media type of the streamed response, which is requested by the Accept header.
+const ContentTypeNDJSON = "application/x-ndjson"
+
+// Request is the body of POST, which is converted into the arguments of the command.
+type Request struct {
+	// Flags are the values of the flags by name. A value is a string, a number or a bool,
+	// or an array of them to specify the flag repeatedly.
+	Flags map[string]any `json:"flags,omitempty"`
+	// Args are the positional arguments.
+	Args []string `json:"args,omitempty"`
+	// Stdin is the standard input of the command.
+	Stdin string `json:"stdin,omitempty"`
+}
+
+// Response is the body of the response of POST.
+type Response struct {
+	ExitCode int    `json:"exitCode"`
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+}
+
+// Event is a line of the streamed response. Each write to OutWriter or ErrWriter is sent as an event with Stream and Data,
+// and the last event has ExitCode.
+type Event struct {
+	// Stream is "stdout" or "stderr".
+	Stream   string `json:"stream,omitempty"`
+	Data     string `json:"data,omitempty"`
+	ExitCode *int   `json:"exitCode,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Handler is the http.Handler which runs the commands of the trees made by a constructor.
+//
+//	GET  /         returns the schema of the command tree (Command) in JSON.
+//	POST /<path>   runs the command at path with the Request, e.g. POST /mod/edit runs "mod edit".
+//	               The command must not have subcommands.
+//
+// The command is run with the context of the request. The outputs are returned as a Response in JSON,
+// or streamed as the lines of Event in JSON while the command runs if the request accepts ContentTypeNDJSON.
+// The exit code of the command doesn't change the status code, which is 200 once the command is run.
+//
+// The hidden, the deprecated and the interactive commands, e.g. Shell, are neither listed nor run.
+// The request to them is answered with 404, as well as the one to the commands which have subcommands, including the root.
+// WithCommandFilter restricts the exposed commands further.
+type Handler struct {
+	invoker *mycmd.Invoker
+	root    *mycmd.Root
+	schema  *Command
+	allow   func(path []string) bool
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+// Option configures Handler.
+type Option func(h *Handler)
+
+// WithCommandFilter exposes the command at path, e.g. ["mod", "edit"], only if allow returns true for it.
+// The descendants of the command which is not exposed are not exposed either.
+func WithCommandFilter(allow func(path []string) bool) Option {
+	return func(h *Handler) {
+		h.allow = allow
+	}
+}
+
+// NewHandler returns the Handler of the trees made by newRoot.
+// newRoot must return a new tree on each call, since the concurrent requests are run on different trees.
+func NewHandler(newRoot func() *mycmd.Root, opts ...Option) *Handler {
+	root := newRoot()
+	h := &Handler{
+		invoker: mycmd.NewInvoker(newRoot),
+		root:    root,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.schema = newSchema(root, []string{}, nil, h.allow)
+	return h
+}
+
+// Schema returns the schema of the command tree.
+func (h *Handler) Schema() *Command {
+	return h.schema
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := []string{}
+	if p := strings.Trim(r.URL.Path, "/"); p != "" {
+		path = strings.Split(p, "/")
+	}
+
+	switch {
+	case r.Method == http.MethodGet && len(path) == 0:
+		writeJSON(w, http.StatusOK, h.schema)
+	case r.Method == http.MethodPost:
+		h.run(w, r, path)
+	default:
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: fmt.Sprintf("method %s is not allowed", r.Method)})
+	}
+}
+
+func (h *Handler) run(w http.ResponseWriter, r *http.Request, path []string) {
+	cmd := h.schema.lookup(path)
+	if cmd == nil || !cmd.runnable {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: fmt.Sprintf("unknown command (%s)", strings.Join(path, " "))})
+		return
+	}
+
+	req := Request{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("invalid request: %s", err)})
+		return
+	}
+	args, err := h.root.CommandLine(path, req.Flags, req.Args)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), ContentTypeNDJSON) {
+		h.stream(w, r, args, req.Stdin)
+		return
+	}
+
+	var stdout, stderr syncbuf.Buffer
+	inv := h.invoker.Invoke(r.Context(), args, strings.NewReader(req.Stdin), &stdout, &stderr)
+	writeJSON(w, http.StatusOK, Response{
+		ExitCode: inv.ExitCode,
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+	})
+}
+
+func (h *Handler) stream(w http.ResponseWriter, r *http.Request, args []string, stdin string) {
+	w.Header().Set("Content-Type", ContentTypeNDJSON)
+	w.WriteHeader(http.StatusOK)
+	events := &eventEncoder{enc: json.NewEncoder(w)}
+	events.flusher, _ = w.(http.Flusher)
+
+	inv := h.invoker.Invoke(r.Context(), args,
+		strings.NewReader(stdin), events.writer("stdout"), events.writer("stderr"),
+	)
+	events.encode(Event{ExitCode: &inv.ExitCode})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+// eventEncoder writes the events to the response one by one.
+type eventEncoder struct {
+	mu      sync.Mutex
+	enc     *json.Encoder
+	flusher http.Flusher
+}
+
+func (e *eventEncoder) encode(ev Event) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.enc.Encode(ev); err != nil {
+		return err
+	}
+	if e.flusher != nil {
+		e.flusher.Flush()
+	}
+	return nil
+}
+
+func (e *eventEncoder) writer(stream string) io.Writer {
+	return writerFunc(func(p []byte) (int, error) {
+		if err := e.encode(Event{Stream: stream, Data: string(p)}); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	})
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
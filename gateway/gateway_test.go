@@ -0,0 +1,283 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kmio11/mycmd"
+	"github.com/kmio11/mycmd/wflag"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestRoot returns the tree which has the exposed commands and the ones which are not exposed.
+func newTestRoot() *mycmd.Root {
+	echo := func(name string, opts ...mycmd.Option) *mycmd.FuncCommand {
+		var upper *bool
+		opts = append(opts,
+			mycmd.WithFlags(func(fs *wflag.FlagSet) {
+				upper = fs.Bool("upper", false, "print in upper case")
+				fs.ArgString(0, "message", "the message to print")
+			}),
+			mycmd.WithRun(func(ctx context.Context, cmd *mycmd.FuncCommand) error {
+				msg := strings.Join(cmd.FS().Args(), " ")
+				if msg == "-" {
+					b, err := io.ReadAll(cmd.InReader())
+					if err != nil {
+						return err
+					}
+					msg = string(b)
+				}
+				if *upper {
+					msg = strings.ToUpper(msg)
+				}
+				cmd.Print(msg)
+				return nil
+			}),
+		)
+		return mycmd.MustNew(name, opts...)
+	}
+
+	root := mycmd.NewRoot("app").AddCommands(
+		echo("echo", mycmd.WithShort("print the message"), mycmd.WithAliases("e")),
+		echo("secret", mycmd.WithHidden()),
+		echo("old", mycmd.WithDeprecated("use 'echo' instead")),
+		mycmd.NewParentBase("group", mycmd.BaseConfig{}).AddCommands(
+			echo("leaf"),
+			echo("hidden", mycmd.WithHidden()),
+		),
+		mycmd.NewShell("shell", mycmd.BaseConfig{}),
+	)
+	root.PersistentFS().Bool("verbose", false, "verbose output")
+	return root
+}
+
+func TestHandler_Schema(t *testing.T) {
+	schema := NewHandler(newTestRoot).Schema()
+
+	names := []string{}
+	for _, c := range schema.Commands {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"echo", "group"}, names)
+	assert.False(t, schema.runnable)
+
+	echo := schema.Commands[0]
+	assert.Equal(t, "/echo", echo.Path)
+	assert.Equal(t, []string{"e"}, echo.Aliases)
+	assert.Equal(t, []Arg{{Index: 0, Name: "message", Usage: "the message to print"}}, echo.Args)
+	assert.Equal(t, []Flag{
+		{Name: "upper", Type: "bool", Usage: "print in upper case", Default: "false"},
+		{Name: "verbose", Type: "bool", Usage: "verbose output", Default: "false"},
+	}, echo.Flags)
+	assert.True(t, echo.runnable)
+
+	group := schema.Commands[1]
+	assert.False(t, group.runnable)
+	assert.Len(t, group.Commands, 1)
+	assert.Equal(t, "/group/leaf", group.Commands[0].Path)
+}
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	server := httptest.NewServer(NewHandler(newTestRoot))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		accept     string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "schema",
+			method:     http.MethodGet,
+			path:       "/",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "run",
+			path:       "/echo",
+			body:       `{"flags": {"upper": true, "verbose": true}, "args": ["hello"]}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"exitCode": 0, "stdout": "HELLO", "stderr": ""}`,
+		},
+		{
+			name:       "alias",
+			path:       "/e",
+			body:       `{"args": ["hello"]}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"exitCode": 0, "stdout": "hello", "stderr": ""}`,
+		},
+		{
+			name:       "stdin",
+			path:       "/group/leaf",
+			body:       `{"args": ["-"], "stdin": "from stdin"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"exitCode": 0, "stdout": "from stdin", "stderr": ""}`,
+		},
+		{
+			name:       "stream",
+			path:       "/echo",
+			body:       `{"args": ["hello"]}`,
+			accept:     ContentTypeNDJSON,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"stream":"stdout","data":"hello"}` + "\n" + `{"exitCode":0}` + "\n",
+		},
+		{
+			name:       "parse_error",
+			path:       "/echo",
+			body:       `{"flags": {"upper": "yes"}}`,
+			wantStatus: http.StatusOK,
+			wantBody: `{"exitCode": 2, "stdout": "", "stderr": "ERROR : invalid argument \"yes\" for \"--upper\" flag: ` +
+				`strconv.ParseBool: parsing \"yes\": invalid syntax\nRun 'app help echo' for usage.\n"}`,
+		},
+		{
+			name:       "args_are_not_flags",
+			path:       "/echo",
+			body:       `{"args": ["--upper"]}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"exitCode": 0, "stdout": "--upper", "stderr": ""}`,
+		},
+		{
+			name:       "unknown_command",
+			path:       "/unknown",
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error": "unknown command (unknown)"}`,
+		},
+		{
+			name:       "hidden_command",
+			path:       "/secret",
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error": "unknown command (secret)"}`,
+		},
+		{
+			name:       "deprecated_command",
+			path:       "/old",
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error": "unknown command (old)"}`,
+		},
+		{
+			name:       "shell",
+			path:       "/shell",
+			body:       `{"stdin": "secret hello\n"}`,
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error": "unknown command (shell)"}`,
+		},
+		{
+			name:       "root",
+			path:       "/",
+			body:       `{"args": ["secret"]}`,
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error": "unknown command ()"}`,
+		},
+		{
+			name:       "parent_command",
+			path:       "/group",
+			body:       `{"args": ["hidden"]}`,
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error": "unknown command (group)"}`,
+		},
+		{
+			name:       "hidden_subcommand",
+			path:       "/group/hidden",
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error": "unknown command (group hidden)"}`,
+		},
+		{
+			name:       "unknown_flag",
+			path:       "/echo",
+			body:       `{"flags": {"race": true}}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error": "unknown flag --race for app echo"}`,
+		},
+		{
+			name:       "invalid_request",
+			path:       "/echo",
+			body:       `{"args": "hello"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "method_not_allowed",
+			method:     http.MethodGet,
+			path:       "/echo",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantBody:   `{"error": "method GET is not allowed"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req, err := http.NewRequest(method, server.URL+tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", tt.accept)
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			switch {
+			case tt.wantBody == "":
+			case tt.accept == ContentTypeNDJSON:
+				assert.Equal(t, tt.wantBody, string(body))
+			default:
+				assert.JSONEq(t, tt.wantBody, string(body))
+			}
+		})
+	}
+}
+
+func TestWithCommandFilter(t *testing.T) {
+	handler := NewHandler(newTestRoot, WithCommandFilter(func(path []string) bool {
+		return path[0] == "group"
+	}))
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	schema := Command{}
+	res, err := http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&schema))
+	res.Body.Close()
+	assert.Len(t, schema.Commands, 1)
+	assert.Equal(t, "/group/leaf", schema.Commands[0].Commands[0].Path)
+
+	for path, want := range map[string]int{
+		"/group/leaf": http.StatusOK,
+		"/echo":       http.StatusNotFound,
+	} {
+		res, err := http.Post(server.URL+path, "application/json", strings.NewReader(`{}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		assert.Equal(t, want, res.StatusCode, path)
+	}
+
+	// the filtered command is not run through the root either.
+	res, err = http.Post(server.URL+"/", "application/json", strings.NewReader(`{"args": ["echo", "hello"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
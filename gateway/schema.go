@@ -0,0 +1,138 @@
+package gateway
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/kmio11/mycmd"
+	"github.com/kmio11/mycmd/wflag"
+	"github.com/spf13/pflag"
+)
+
+// Command is the schema of a command, which is returned by GET /.
+type Command struct {
+	Name string `json:"name"`
+	// Path is the path to POST to run the command, e.g. "/mod/edit". The commands which have subcommands cannot be run.
+	Path    string   `json:"path"`
+	Short   string   `json:"short,omitempty"`
+	Aliases []string `json:"aliases,omitempty"`
+	Flags   []Flag   `json:"flags,omitempty"`
+	Args    []Arg    `json:"args,omitempty"`
+	// Commands are the exposed subcommands.
+	Commands []*Command `json:"commands,omitempty"`
+
+	// runnable is false for the commands which have subcommands, even if none of them is exposed.
+	runnable bool
+}
+
+// Flag is the schema of a flag available to a command, including the ones inherited from its parents.
+type Flag struct {
+	Name      string `json:"name"`
+	Shorthand string `json:"shorthand,omitempty"`
+	Type      string `json:"type"`
+	Usage     string `json:"usage,omitempty"`
+	Default   string `json:"default,omitempty"`
+	Required  bool   `json:"required,omitempty"`
+}
+
+// Arg is the schema of a positional argument.
+type Arg struct {
+	Index    int    `json:"index"`
+	Name     string `json:"name"`
+	Usage    string `json:"usage,omitempty"`
+	Required bool   `json:"required,omitempty"`
+}
+
+// newSchema returns the schema of c and its exposed descendants. inherited are the persistent flags of the parents of c.
+// The hidden, the deprecated and the interactive commands are not exposed, nor the ones at the paths which allow returns false for if it is not nil.
+func newSchema(c mycmd.Command, path []string, inherited []*wflag.FlagSet, allow func(path []string) bool) *Command {
+	s := &Command{
+		Name:  c.Name(),
+		Path:  "/" + strings.Join(path, "/"),
+		Short: c.ShortDescription(),
+	}
+	_, isParent := c.(mycmd.ParentCommand)
+	s.runnable = !isParent
+	if v, ok := c.(mycmd.AliasesSupported); ok {
+		s.Aliases = v.Aliases()
+	}
+
+	// the own flags take precedence over the inherited ones like the parsing does.
+	sets := []*wflag.FlagSet{}
+	if v, ok := c.(mycmd.FlagSetSupported); ok {
+		sets = append(sets, v.FS())
+		for _, a := range v.FS().Arguments() {
+			s.Args = append(s.Args, Arg{Index: a.Index, Name: a.Name, Usage: a.Usage, Required: a.Required})
+		}
+	}
+	if v, ok := c.(mycmd.PersistentFlagSetSupported); ok {
+		inherited = append(slices.Clip(inherited), v.PersistentFS())
+	}
+	for i := len(inherited) - 1; i >= 0; i-- {
+		sets = append(sets, inherited[i])
+	}
+	for _, set := range sets {
+		set.VisitAll(func(f *pflag.Flag) {
+			if f.Hidden || s.flag(f.Name) != nil {
+				return
+			}
+			s.Flags = append(s.Flags, Flag{
+				Name:      f.Name,
+				Shorthand: f.Shorthand,
+				Type:      f.Value.Type(),
+				Usage:     f.Usage,
+				Default:   f.DefValue,
+				Required:  set.IsRequired(f.Name),
+			})
+		})
+	}
+
+	if v, ok := c.(mycmd.ParentCommand); ok {
+		for _, sub := range v.Commands() {
+			subPath := append(slices.Clip(path), sub.Name())
+			if !exposed(sub) || (allow != nil && !allow(subPath)) {
+				continue
+			}
+			s.Commands = append(s.Commands, newSchema(sub, subPath, inherited, allow))
+		}
+	}
+	return s
+}
+
+// exposed reports whether c can be run through the gateway,
+// which is false for the hidden, the deprecated and the interactive commands, e.g. Shell.
+func exposed(c mycmd.Command) bool {
+	if _, ok := c.(*mycmd.Shell); ok {
+		return false
+	}
+	if v, ok := c.(mycmd.HiddenSupported); ok && v.Hidden() {
+		return false
+	}
+	if v, ok := c.(mycmd.DeprecatedSupported); ok && v.Deprecated() != "" {
+		return false
+	}
+	return true
+}
+
+// flag returns the flag of the name, or nil.
+func (s *Command) flag(name string) *Flag {
+	for i := range s.Flags {
+		if s.Flags[i].Name == name {
+			return &s.Flags[i]
+		}
+	}
+	return nil
+}
+
+// lookup returns the exposed descendant of s at path, whose elements are the names or the aliases of the commands, or nil.
+func (s *Command) lookup(path []string) *Command {
+	if len(path) == 0 {
+		return s
+	}
+	for _, sub := range s.Commands {
+		if sub.Name == path[0] || slices.Contains(sub.Aliases, path[0]) {
+			return sub.lookup(path[1:])
+		}
+	}
+	return nil
+}
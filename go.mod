@@ -1,16 +1,16 @@
 module github.com/kmio11/mycmd
 
-go 1.18
+go 1.21
 
 require (
 	github.com/kmio11/flag-validator/pflag-validator v0.1.1
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/spf13/pflag v1.0.5
 	github.com/stretchr/testify v1.8.4
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/exp v0.0.0-20230905200255-921286631fa9 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
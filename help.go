@@ -1,30 +1,61 @@
 package mycmd
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+	"text/template"
+
+	"github.com/kmio11/mycmd/wflag"
+	"github.com/spf13/pflag"
 )
 
-var _ SubCommand = (*Help)(nil)
+var _ interface {
+	SubCommand
+	FlagSetSupported
+	ResetSupported
+} = (*Help)(nil)
 
 type Help struct {
+	fs        *wflag.FlagSet
 	parent    Command
 	outWriter io.Writer
 	errWriter io.Writer
+	inReader  io.Reader
+
+	flagAll    *bool
+	flagHidden *bool
+	flagFlags  *bool
+	flagDepth  *int
 
 	target        Command
+	tree          bool
+	topic         *HelpTopic
+	resolvedPath  []string
 	unknownTarget string
 }
 
+// helpTreeTopic is the built-in help topic which shows the tree of the commands.
+const helpTreeTopic = "tree"
+
 func NewHelp(parent ParentCommand) *Help {
-	return &Help{
+	c := &Help{
+		fs:        wflag.NewFlagSet("help", pflag.ContinueOnError),
 		parent:    parent,
 		outWriter: os.Stdout,
 		errWriter: os.Stderr,
+		inReader:  os.Stdin,
 	}
+	c.fs.SortFlags = false
+	c.flagAll = c.fs.BoolP("all", "a", false, "print the tree of all the commands")
+	c.flagHidden = c.fs.Bool("hidden", false, "include the hidden commands in the tree")
+	c.flagFlags = c.fs.Bool("flags", false, "include the flags of each command in the tree")
+	c.flagDepth = c.fs.Int("depth", 0, "limit the depth of the tree (0 means no limit)")
+	return c
 }
 
 func (c *Help) Name() string {
@@ -35,31 +66,88 @@ func (c *Help) ShortDescription() string {
 	return ""
 }
 
+const helpUsageTemplate = `
+Usage:
+
+  {{.FullName}} [--all [--hidden] [--flags] [--depth n]] [command]...
+  {{.FullName}} [--hidden] [--flags] [--depth n] [command]... tree
+
+Flags:
+
+{{.Flags}}
+`
+
 func (c *Help) Usage() string {
-	return ""
+	usageData := map[string]any{
+		"FullName": strings.Join(FullName(c), " "),
+		"Flags":    strings.TrimRight(c.fs.FlagUsages(), "\n"),
+	}
+
+	tmpl := template.Must(template.New("HelpUsage").Parse(helpUsageTemplate))
+	var buf bytes.Buffer
+	tmpl.Execute(&buf, usageData)
+
+	return buf.String()
 }
 
+// FS returns FlagSet
+func (c *Help) FS() *wflag.FlagSet {
+	return c.fs
+}
+
+// Parse resolves the command whose help is shown from the path of the command names (or aliases) in args.
+// If args is empty, the help of the parent is shown.
+// The last arg "tree" shows the tree of the resolved command as --all does,
+// unless it is the name of a subcommand or a help topic.
 func (c *Help) Parse(args []string) error {
-	if len(args) == 0 {
-		// subcommand is not specified. help for parent
-		c.target = c.parent
-		return nil
-	}
-	if parent, ok := c.parent.(ParentCommand); ok {
-		for _, sub := range parent.Commands() {
-			sub := sub
-			if args[0] == sub.Name() {
-				// help for subcommand
-				c.target = sub
-				break
-			}
+	err := c.fs.Parse(args)
+	if err != nil {
+		if errors.Is(err, wflag.ErrHelp) {
+			c.target = c
+			return nil
 		}
+		return err
 	}
+	args = c.fs.Args()
 
-	c.unknownTarget = args[0]
+	c.target = c.parent
+	for i, name := range args {
+		var next Command
+		if parent, ok := c.target.(ParentCommand); ok {
+			next = findCommand(parent, name)
+		}
+		if i == 0 && name == c.Name() {
+			next = c
+		}
+		if topic := findHelpTopic(c.target, name); next == nil && topic != nil && i == len(args)-1 {
+			c.target = nil
+			c.topic = topic
+			return nil
+		}
+		if next == nil && name == helpTreeTopic && i == len(args)-1 {
+			c.tree = true
+			return nil
+		}
+		if next == nil {
+			c.target = nil
+			c.resolvedPath = args[:i]
+			c.unknownTarget = strings.Join(args[:i+1], " ")
+			return nil
+		}
+		c.target = next
+	}
 	return nil
 }
 
+func (c *Help) Reset() {
+	c.fs.Reset()
+	c.target = nil
+	c.tree = false
+	c.topic = nil
+	c.resolvedPath = nil
+	c.unknownTarget = ""
+}
+
 func (c *Help) IsHelpRequested(err error) bool {
 	return false
 }
@@ -70,14 +158,26 @@ func (c *Help) Execute() int {
 }
 
 func (c *Help) ExecuteContext(ctx context.Context) int {
+	if c.target != nil && (*c.flagAll || c.tree) {
+		fmt.Fprint(c.outWriter, Tree(c.target, TreeOptions{
+			Depth:  *c.flagDepth,
+			Hidden: *c.flagHidden,
+			Flags:  *c.flagFlags,
+		}))
+		return 0
+	}
 	if c.target != nil {
 		fmt.Fprintln(c.outWriter, c.target.Usage())
 		return 0
 	}
-	fullName := strings.Join(FullName(c), " ")
+	if c.topic != nil {
+		fmt.Fprintln(c.outWriter, c.topic.Usage())
+		return 0
+	}
+	fullName := FullName(c)
 	fmt.Fprintf(
 		c.errWriter, "%s %s: unknown help topic. Run '%s'.\n",
-		fullName, c.unknownTarget, fullName,
+		strings.Join(fullName, " "), c.unknownTarget, strings.Join(append(fullName, c.resolvedPath...), " "),
 	)
 	return 2
 }
@@ -98,6 +198,10 @@ func (c *Help) SetErrWriter(w io.Writer) {
 	c.errWriter = w
 }
 
+func (c *Help) SetInReader(r io.Reader) {
+	c.inReader = r
+}
+
 func (c *Help) Print(msg string) {
 	fmt.Fprint(c.outWriter, msg)
 }
@@ -0,0 +1,98 @@
+package instrument
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kmio11/mycmd"
+)
+
+var _ mycmd.Instrumentation = (*JSONLinesExporter)(nil)
+
+// Record is a line written by JSONLinesExporter for each ended span.
+type Record struct {
+	ID          uint64    `json:"id"`
+	ParentID    uint64    `json:"parentId,omitempty"`
+	Kind        string    `json:"kind"`
+	Name        string    `json:"name"`
+	CommandPath []string  `json:"commandPath,omitempty"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	// DurationMS is the duration in milliseconds.
+	DurationMS float64 `json:"durationMs"`
+	ExitCode   int     `json:"exitCode"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// JSONLinesExporter writes a Record in JSON for each ended span, line by line.
+// The spans are written in the order they end, so the nested spans precede their parents.
+// It can be shared by the trees run concurrently.
+type JSONLinesExporter struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	closer io.Closer
+	// err is the first error of writing, which is returned by Close.
+	err error
+}
+
+// NewJSONLinesExporter returns the JSONLinesExporter writing to w.
+func NewJSONLinesExporter(w io.Writer) *JSONLinesExporter {
+	return &JSONLinesExporter{
+		enc: json.NewEncoder(w),
+	}
+}
+
+// OpenJSONLinesFile returns the JSONLinesExporter appending to the file of name, which is created if it doesn't exist.
+// The file is closed by Close.
+func OpenJSONLinesFile(name string) (*JSONLinesExporter, error) {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	e := NewJSONLinesExporter(f)
+	e.closer = f
+	return e, nil
+}
+
+func (e *JSONLinesExporter) StartSpan(span *mycmd.Span) {}
+
+// EndSpan writes the record of span. The error of writing doesn't affect the command, and is returned by Close.
+func (e *JSONLinesExporter) EndSpan(span *mycmd.Span) {
+	rec := Record{
+		ID:          span.ID,
+		Kind:        span.Kind.String(),
+		Name:        span.Name,
+		CommandPath: span.CommandPath,
+		Start:       span.Start,
+		End:         span.End,
+		DurationMS:  float64(span.Duration()) / float64(time.Millisecond),
+		ExitCode:    span.ExitCode,
+	}
+	if span.Parent != nil {
+		rec.ParentID = span.Parent.ID
+	}
+	if span.Err != nil {
+		rec.Error = span.Err.Error()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.enc.Encode(rec); err != nil && e.err == nil {
+		e.err = err
+	}
+}
+
+// Close closes the file opened by OpenJSONLinesFile, and returns the first error of writing or closing.
+func (e *JSONLinesExporter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var err error
+	if e.closer != nil {
+		err = e.closer.Close()
+	}
+	return errors.Join(e.err, err)
+}
@@ -0,0 +1,103 @@
+package instrument
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// decodeRecords returns the records written in r.
+func decodeRecords(t *testing.T, r io.Reader) []Record {
+	t.Helper()
+	records := []Record{}
+	dec := json.NewDecoder(r)
+	for dec.More() {
+		rec := Record{}
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestJSONLinesExporter(t *testing.T) {
+	var buf bytes.Buffer
+	exporter := NewJSONLinesExporter(&buf)
+	root := newTestRoot(exporter)
+	root.SetOutWriter(io.Discard)
+	root.SetErrWriter(io.Discard)
+
+	assert.Equal(t, 0, root.ParseAndExecuteContext(context.Background(), []string{"mod", "edit"}))
+	assert.Equal(t, 3, root.ParseAndExecuteContext(context.Background(), []string{"fail"}))
+	assert.NoError(t, exporter.Close())
+
+	// the nested spans are written before their parents.
+	records := decodeRecords(t, &buf)
+	kinds := []string{}
+	for _, rec := range records {
+		kinds = append(kinds, rec.Kind+" "+rec.Name)
+	}
+	assert.Equal(t, []string{
+		"parse parse", "execute edit", "execute mod", "execute test", "invocation test",
+		"parse parse", "execute fail", "execute test", "invocation test",
+	}, kinds)
+
+	invocation := records[4]
+	assert.Equal(t, uint64(0), invocation.ParentID)
+	assert.Equal(t, []string{"test", "mod", "edit"}, invocation.CommandPath)
+	assert.Equal(t, invocation.ID, records[0].ParentID)
+	assert.Equal(t, invocation.ID, records[3].ParentID)
+	assert.Equal(t, records[3].ID, records[2].ParentID)
+	assert.Equal(t, records[2].ID, records[1].ParentID)
+	assert.False(t, invocation.End.Before(invocation.Start))
+
+	failed := records[8]
+	assert.Equal(t, 3, failed.ExitCode)
+	assert.Equal(t, "failed", failed.Error)
+}
+
+type errWriter struct{}
+
+func (errWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestJSONLinesExporter_Close(t *testing.T) {
+	exporter := NewJSONLinesExporter(errWriter{})
+	root := newTestRoot(exporter)
+	root.SetOutWriter(io.Discard)
+
+	// the error of writing doesn't affect the command.
+	assert.Equal(t, 0, root.ParseAndExecuteContext(context.Background(), []string{"ok"}))
+	assert.EqualError(t, exporter.Close(), "write failed")
+}
+
+func TestOpenJSONLinesFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "spans.jsonl")
+	for i := 0; i < 2; i++ {
+		exporter, err := OpenJSONLinesFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		root := newTestRoot(exporter)
+		root.SetOutWriter(io.Discard)
+		assert.Equal(t, 0, root.ParseAndExecuteContext(context.Background(), []string{"ok"}))
+		assert.NoError(t, exporter.Close())
+	}
+
+	// the records are appended to the file.
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	assert.Len(t, decodeRecords(t, f), 8)
+}
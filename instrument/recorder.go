@@ -0,0 +1,76 @@
+// Package instrument provides the implementations of mycmd.Instrumentation.
+package instrument
+
+import (
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/kmio11/mycmd"
+)
+
+var _ mycmd.Instrumentation = (*Recorder)(nil)
+
+// Recorder records the ended spans in memory, which is useful to assert the spans in tests.
+// It can be shared by the trees run concurrently.
+type Recorder struct {
+	mu    sync.Mutex
+	spans []recorded
+}
+
+// recorded is the copy of an ended span, which doesn't refer to the span reported to the Recorder.
+type recorded struct {
+	span     mycmd.Span
+	parentID uint64
+}
+
+// NewRecorder returns an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+func (r *Recorder) StartSpan(span *mycmd.Span) {}
+
+func (r *Recorder) EndSpan(span *mycmd.Span) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec := recorded{span: *span}
+	if span.Parent != nil {
+		rec.parentID = span.Parent.ID
+	}
+	rec.span.Parent = nil
+	rec.span.CommandPath = slices.Clone(span.CommandPath)
+	r.spans = append(r.spans, rec)
+}
+
+// Spans returns the copies of the ended spans in the order they started.
+// The Parent of a span points to the copy of its parent in the returned slice, or is nil if the parent is not ended yet.
+func (r *Recorder) Spans() []mycmd.Span {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	spans := make([]mycmd.Span, len(r.spans))
+	for i, rec := range r.spans {
+		spans[i] = rec.span
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i].ID < spans[j].ID
+	})
+
+	index := make(map[uint64]int, len(spans))
+	for i := range spans {
+		index[spans[i].ID] = i
+	}
+	for _, rec := range r.spans {
+		if i, ok := index[rec.parentID]; ok && rec.parentID != 0 {
+			spans[index[rec.span.ID]].Parent = &spans[i]
+		}
+	}
+	return spans
+}
+
+// Reset forgets the recorded spans.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = nil
+}
@@ -0,0 +1,199 @@
+package instrument
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/kmio11/mycmd"
+	"github.com/kmio11/mycmd/wflag"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestRoot returns the tree which reports its spans to ins.
+func newTestRoot(ins mycmd.Instrumentation) *mycmd.Root {
+	run := func(ctx context.Context, cmd *mycmd.FuncCommand) error {
+		return nil
+	}
+	return mycmd.NewRoot("test").AddCommands(
+		mycmd.NewParentBase("mod", mycmd.BaseConfig{}).AddCommands(
+			mycmd.MustNew("edit", mycmd.WithRun(run)),
+		),
+		mycmd.MustNew("build",
+			mycmd.WithFlags(func(fs *wflag.FlagSet) {
+				fs.String("out", "", "output file")
+				_ = fs.MarkRequired("out")
+			}),
+			mycmd.WithRun(run),
+		),
+		mycmd.MustNew("ok", mycmd.WithRun(run)),
+		mycmd.MustNew("fail", mycmd.WithRun(func(ctx context.Context, cmd *mycmd.FuncCommand) error {
+			return mycmd.Exit(3, errors.New("failed"))
+		})),
+		mycmd.MustNew("panic", mycmd.WithRun(func(ctx context.Context, cmd *mycmd.FuncCommand) error {
+			panic("boom")
+		})),
+		mycmd.NewShell("shell", mycmd.BaseConfig{}),
+	).EnableInstrumentation(ins)
+}
+
+// summary describes each span with its kind, name, command path and exit code, indented by its depth.
+func summary(spans []mycmd.Span) []string {
+	lines := []string{}
+	for _, s := range spans {
+		depth := 0
+		for p := s.Parent; p != nil; p = p.Parent {
+			depth++
+		}
+		line := fmt.Sprintf("%s%s %s (%s) exit=%d", strings.Repeat("  ", depth), s.Kind, s.Name, strings.Join(s.CommandPath, " "), s.ExitCode)
+		if s.Err != nil {
+			line += fmt.Sprintf(" err=%q", s.Err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestRecorder(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		stdin string
+		want  []string
+	}{
+		{
+			name: "nested",
+			args: []string{"mod", "edit"},
+			want: []string{
+				"invocation test (test mod edit) exit=0",
+				"  parse parse (test mod edit) exit=0",
+				"  execute test (test) exit=0",
+				"    execute mod (test mod) exit=0",
+				"      execute edit (test mod edit) exit=0",
+			},
+		},
+		{
+			name: "parse_error",
+			args: []string{"build"},
+			want: []string{
+				`invocation test (test build) exit=2 err="The flag [--out] is required"`,
+				`  parse parse (test build) exit=2 err="The flag [--out] is required"`,
+			},
+		},
+		{
+			name: "help",
+			args: []string{"build", "--help"},
+			want: []string{
+				"invocation test (test help) exit=0",
+				"  parse parse (test help) exit=0",
+				"  execute test (test) exit=0",
+				"    execute help (test help) exit=0",
+			},
+		},
+		{
+			name: "execution_error",
+			args: []string{"fail"},
+			want: []string{
+				`invocation test (test fail) exit=3 err="failed"`,
+				`  parse parse (test fail) exit=0`,
+				`  execute test (test) exit=3 err="failed"`,
+				`    execute fail (test fail) exit=3 err="failed"`,
+			},
+		},
+		{
+			name:  "shell",
+			args:  []string{"shell"},
+			stdin: "ok\nexit 3\n",
+			want: []string{
+				"invocation test (test shell) exit=3",
+				"  parse parse (test shell) exit=0",
+				"  execute test (test) exit=3",
+				"    execute shell (test shell) exit=3",
+				"      invocation test (test ok) exit=0",
+				"        parse parse (test ok) exit=0",
+				"        execute test (test) exit=0",
+				"          execute ok (test ok) exit=0",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := NewRecorder()
+			root := newTestRoot(recorder)
+			root.SetOutWriter(io.Discard)
+			root.SetErrWriter(io.Discard)
+			root.SetInReader(strings.NewReader(tt.stdin))
+
+			root.ParseAndExecuteContext(context.Background(), tt.args)
+
+			spans := recorder.Spans()
+			assert.Equal(t, tt.want, summary(spans))
+			for _, s := range spans {
+				assert.False(t, s.End.Before(s.Start), "span %d ends before it starts", s.ID)
+			}
+		})
+	}
+}
+
+func TestRecorder_executionError(t *testing.T) {
+	recorder := NewRecorder()
+	root := newTestRoot(recorder)
+	root.SetErrWriter(io.Discard)
+
+	assert.Equal(t, 3, root.ParseAndExecuteContext(context.Background(), []string{"fail"}))
+	var exitErr *mycmd.ExitError
+	assert.ErrorAs(t, recorder.Spans()[0].Err, &exitErr)
+
+	// the error of the previous execution is not left.
+	recorder.Reset()
+	assert.Equal(t, 0, root.ParseAndExecuteContext(context.Background(), []string{"ok"}))
+	assert.Equal(t, []string{
+		`invocation test (test ok) exit=0`,
+		`  parse parse (test ok) exit=0`,
+		`  execute test (test) exit=0`,
+		`    execute ok (test ok) exit=0`,
+	}, summary(recorder.Spans()))
+}
+
+func TestRecorder_panic(t *testing.T) {
+	recorder := NewRecorder()
+	root := newTestRoot(recorder)
+
+	assert.PanicsWithValue(t, "boom", func() {
+		root.ParseAndExecuteContext(context.Background(), []string{"panic"})
+	})
+	assert.Equal(t, []string{
+		`invocation test (test panic) exit=1 err="panic: boom"`,
+		`  parse parse (test panic) exit=0`,
+		`  execute test (test) exit=1 err="panic: boom"`,
+		`    execute panic (test panic) exit=1 err="panic: boom"`,
+	}, summary(recorder.Spans()))
+}
+
+func TestRecorder_Spans(t *testing.T) {
+	recorder := NewRecorder()
+	invocation := &mycmd.Span{ID: 1, Kind: mycmd.SpanInvocation, Name: "test", CommandPath: []string{"test"}}
+	parse := &mycmd.Span{ID: 2, Parent: invocation, Kind: mycmd.SpanParse, Name: "parse"}
+	execute := &mycmd.Span{ID: 3, Parent: invocation, Kind: mycmd.SpanExecute, Name: "test"}
+
+	recorder.EndSpan(parse)
+	spans := recorder.Spans()
+	// the parent which is not ended yet is not referred to.
+	assert.Nil(t, spans[0].Parent)
+
+	recorder.EndSpan(invocation)
+	recorder.EndSpan(execute)
+	spans = recorder.Spans()
+	assert.Len(t, spans, 3)
+	assert.Same(t, &spans[0], spans[1].Parent)
+	assert.Same(t, &spans[0], spans[2].Parent)
+
+	// the copies are not changed by the spans reported to the Recorder.
+	invocation.ExitCode = 1
+	invocation.CommandPath[0] = "changed"
+	assert.Equal(t, 0, spans[1].Parent.ExitCode)
+	assert.Equal(t, []string{"test"}, recorder.Spans()[0].CommandPath)
+}
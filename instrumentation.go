@@ -0,0 +1,202 @@
+package mycmd
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Instrumentation receives the spans of the invocations of the commands, e.g. to trace them or to collect the metrics.
+// It is set by EnableInstrumentation of Root, and used by RunCommandContext and the invocations of the Root.
+// The methods are called synchronously by the invocation, so they should return quickly.
+// They must be safe for concurrent use if the Instrumentation is shared by the trees run concurrently, e.g. by Invoker.
+type Instrumentation interface {
+	// StartSpan is called when span starts. Its End, ExitCode and Err are not set yet.
+	StartSpan(span *Span)
+	// EndSpan is called when span ends. The span is never changed after that.
+	EndSpan(span *Span)
+}
+
+// SpanKind is the kind of Span.
+type SpanKind int
+
+const (
+	// SpanInvocation covers an invocation from parsing the arguments to the end of the execution.
+	// It is named by the invoked command, which is usually the Root.
+	SpanInvocation SpanKind = iota
+	// SpanParse covers parsing the arguments. It is the child of SpanInvocation.
+	SpanParse
+	// SpanExecute covers the execution of a command. The spans of the commands from the invoked one
+	// to the resolved leaf are nested in this order, and the outermost one is the child of SpanInvocation.
+	SpanExecute
+)
+
+func (k SpanKind) String() string {
+	switch k {
+	case SpanInvocation:
+		return "invocation"
+	case SpanParse:
+		return "parse"
+	case SpanExecute:
+		return "execute"
+	}
+	return "unknown"
+}
+
+// Span is a timed operation of an invocation.
+type Span struct {
+	// ID is unique in the process.
+	ID uint64
+	// Parent is the span which this span is nested in, or nil. The invocation run by a command,
+	// e.g. by Shell, is nested in the span of the command if it is run with the context passed to the command.
+	Parent *Span
+	Kind   SpanKind
+	// Name is the name of the command, or "parse" for SpanParse.
+	Name string
+	// CommandPath is the full name of the executed command for SpanExecute. For SpanInvocation and SpanParse,
+	// it is the full name of the command which the arguments resolved to, and is set when parsing ends.
+	CommandPath []string
+	Start       time.Time
+	End         time.Time
+	// ExitCode is the exit code of the invocation, the parsing or the execution.
+	ExitCode int
+	// Err is the error of parsing for SpanParse. The request for the help is not an error.
+	// For SpanInvocation and SpanExecute, it is the error of parsing or of the execution, e.g. the error returned by RunFunc
+	// including ExitError. If the command panics, the spans end with the exit code 1 and the error of the panic value
+	// before the panic continues.
+	Err error
+}
+
+// Duration returns the duration of the span, which is 0 until the span ends.
+func (s *Span) Duration() time.Duration {
+	if s.End.IsZero() {
+		return 0
+	}
+	return s.End.Sub(s.Start)
+}
+
+// EnableInstrumentation makes the invocations of the tree report their spans to ins.
+func (c *Root) EnableInstrumentation(ins Instrumentation) *Root {
+	c.instrumentation = ins
+	return c
+}
+
+// instrumented is implemented by the command which has the Instrumentation of its tree.
+type instrumented interface {
+	instrumentationOf() Instrumentation
+}
+
+func (c *Root) instrumentationOf() Instrumentation {
+	return c.instrumentation
+}
+
+// findInstrumentation returns the Instrumentation of the tree of c, or nil.
+func findInstrumentation(c Command) Instrumentation {
+	for c != nil {
+		if v, ok := c.(instrumented); ok {
+			return v.instrumentationOf()
+		}
+		sub, ok := c.(SubCommand)
+		if !ok {
+			return nil
+		}
+		c = sub.Parent()
+	}
+	return nil
+}
+
+type spanKey struct{}
+
+// spanFromContext returns the span of the command executed with ctx, or nil.
+func spanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+var lastSpanID atomic.Uint64
+
+// tracer records the spans of an invocation. The methods of nil do nothing, which is the case without Instrumentation.
+type tracer struct {
+	ins        Instrumentation
+	invocation *Span
+	parse      *Span
+	executes   []*Span
+}
+
+// startTrace starts the spans of the invocation of c and of parsing, or returns nil if c is not instrumented.
+func startTrace(ctx context.Context, c Command) *tracer {
+	ins := findInstrumentation(c)
+	if ins == nil {
+		return nil
+	}
+	t := &tracer{ins: ins}
+	t.invocation = t.start(spanFromContext(ctx), SpanInvocation, c.Name(), nil)
+	t.parse = t.start(t.invocation, SpanParse, "parse", nil)
+	return t
+}
+
+func (t *tracer) start(parent *Span, kind SpanKind, name string, path []string) *Span {
+	span := &Span{
+		ID:          lastSpanID.Add(1),
+		Parent:      parent,
+		Kind:        kind,
+		Name:        name,
+		CommandPath: path,
+		Start:       time.Now(),
+	}
+	t.ins.StartSpan(span)
+	return span
+}
+
+func (t *tracer) end(span *Span, code int, err error) {
+	span.End = time.Now()
+	span.ExitCode = code
+	span.Err = err
+	t.ins.EndSpan(span)
+}
+
+// parsed ends the span of parsing by c with the resolved command path.
+func (t *tracer) parsed(c Command, code int, err error) {
+	if t == nil {
+		return
+	}
+	path := FullName(parsedLeaf(c))
+	t.invocation.CommandPath = path
+	t.parse.CommandPath = path
+	t.end(t.parse, code, err)
+}
+
+// executing starts the nested spans of the execution from c to the resolved leaf,
+// and returns the context holding the span of the leaf.
+func (t *tracer) executing(ctx context.Context, c Command) context.Context {
+	if t == nil {
+		return ctx
+	}
+	parent := t.invocation
+	for cmd := c; cmd != nil; {
+		parent = t.start(parent, SpanExecute, cmd.Name(), FullName(cmd))
+		t.executes = append(t.executes, parent)
+
+		v, ok := cmd.(interface{ parsed() Command })
+		if !ok {
+			break
+		}
+		cmd = v.parsed()
+	}
+	return context.WithValue(ctx, spanKey{}, parent)
+}
+
+// finish ends the spans which are not ended yet: the span of parsing, the ones of the execution from the leaf,
+// and the span of the invocation.
+func (t *tracer) finish(code int, err error) {
+	if t == nil {
+		return
+	}
+	if t.parse.End.IsZero() {
+		t.end(t.parse, code, err)
+	}
+	for i := len(t.executes) - 1; i >= 0; i-- {
+		t.end(t.executes[i], code, err)
+	}
+	t.end(t.invocation, code, err)
+}
@@ -0,0 +1,39 @@
+// Package term provides the minimal terminal handling used by mycmd.
+package term
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// ErrNotSupported is returned when terminal control is not available on the platform.
+var ErrNotSupported = errors.New("terminal control is not supported on this platform")
+
+// IsTerminal returns true when fd refers to a terminal.
+func IsTerminal(fd uintptr) bool {
+	_, err := getState(fd)
+	return err == nil
+}
+
+// IsTerminalWriter returns true when w is an *os.File connected to a terminal.
+func IsTerminalWriter(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && IsTerminal(f.Fd())
+}
+
+// IsTerminalReader returns true when r is an *os.File connected to a terminal.
+func IsTerminalReader(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	return ok && IsTerminal(f.Fd())
+}
+
+// MakeRaw puts the terminal into raw mode and returns a function restoring the previous state.
+func MakeRaw(fd uintptr) (restore func() error, err error) {
+	return makeRaw(fd)
+}
+
+// DisableEcho turns off the echo of the terminal and returns a function restoring the previous state.
+func DisableEcho(fd uintptr) (restore func() error, err error) {
+	return disableEcho(fd)
+}
@@ -0,0 +1,17 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package term
+
+type state struct{}
+
+func getState(fd uintptr) (*state, error) {
+	return nil, ErrNotSupported
+}
+
+func makeRaw(fd uintptr) (func() error, error) {
+	return nil, ErrNotSupported
+}
+
+func disableEcho(fd uintptr) (func() error, error) {
+	return nil, ErrNotSupported
+}
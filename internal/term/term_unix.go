@@ -0,0 +1,58 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package term
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+func getState(fd uintptr) (*syscall.Termios, error) {
+	var t syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlGetTermios, uintptr(unsafe.Pointer(&t)))
+	if errno != 0 {
+		return nil, errno
+	}
+	return &t, nil
+}
+
+func setState(fd uintptr, t *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlSetTermios, uintptr(unsafe.Pointer(t)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func modify(fd uintptr, f func(t *syscall.Termios)) (func() error, error) {
+	old, err := getState(fd)
+	if err != nil {
+		return nil, err
+	}
+	t := *old
+	f(&t)
+	if err := setState(fd, &t); err != nil {
+		return nil, err
+	}
+	return func() error {
+		return setState(fd, old)
+	}, nil
+}
+
+func makeRaw(fd uintptr) (func() error, error) {
+	return modify(fd, func(t *syscall.Termios) {
+		// same as cfmakeraw(3), but keep output processing so that "\n" still moves to the line head.
+		t.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+		t.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+		t.Cflag &^= syscall.CSIZE | syscall.PARENB
+		t.Cflag |= syscall.CS8
+		t.Cc[syscall.VMIN] = 1
+		t.Cc[syscall.VTIME] = 0
+	})
+}
+
+func disableEcho(fd uintptr) (func() error, error) {
+	return modify(fd, func(t *syscall.Termios) {
+		t.Lflag &^= syscall.ECHO
+	})
+}
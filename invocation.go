@@ -0,0 +1,183 @@
+package mycmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spf13/pflag"
+)
+
+// Invocation is the result of a single parse and execution of a Root.
+type Invocation struct {
+	// Args are the arguments given to the Root.
+	Args []string
+	// CommandPath is the full name of the command which Args resolved to, e.g. ["example", "mod", "edit"].
+	// It is the help command if the help is requested.
+	CommandPath []string
+	// ParseError is the error of parsing Args, or nil if they are parsed successfully.
+	ParseError error
+	// ExitCode is the exit code of the invocation.
+	ExitCode int
+}
+
+// invoke runs a single invocation of c from the fresh state, executing the command by exec. The invocations of c are serialized.
+func (c *Root) invoke(ctx context.Context, args []string, exec func(context.Context, Command) int) *Invocation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.validateInDebugBuild()
+	// nothing is left by the previous invocation, e.g. the parsed subcommand and the flag values.
+	c.Reset()
+
+	inv := &Invocation{Args: args}
+	inv.ExitCode, inv.ParseError = run(ctx, c, args, exec)
+	inv.CommandPath = FullName(parsedLeaf(c))
+	return inv
+}
+
+// Invoker runs the invocations of the command trees made by a constructor concurrently.
+// Each invocation runs on a tree which no other invocation is using, and the trees are reused by the later invocations.
+// It is useful for the long-lived processes, e.g. servers, which run the commands for the concurrent requests.
+// The commands are executed by ExecuteContext with the context of the invocation.
+type Invoker struct {
+	pool sync.Pool
+}
+
+// NewInvoker returns the Invoker of the trees made by newRoot.
+// newRoot must return a new tree on each call, which doesn't share any state with the others.
+func NewInvoker(newRoot func() *Root) *Invoker {
+	return &Invoker{
+		pool: sync.Pool{
+			New: func() any {
+				return newRoot()
+			},
+		},
+	}
+}
+
+// Invoke parses and executes args with the standard input in, the standard output out and the error output errOut.
+func (i *Invoker) Invoke(ctx context.Context, args []string, in io.Reader, out, errOut io.Writer) *Invocation {
+	root := i.pool.Get().(*Root)
+	defer func() {
+		// the tree doesn't hold the streams of the finished invocation.
+		root.SetInReader(strings.NewReader(""))
+		root.SetOutWriter(io.Discard)
+		root.SetErrWriter(io.Discard)
+		i.pool.Put(root)
+	}()
+
+	root.SetInReader(in)
+	root.SetOutWriter(out)
+	root.SetErrWriter(errOut)
+	return root.Invoke(ctx, args)
+}
+
+// CommandLine returns the arguments which run the command at path with the flags and the positional arguments,
+// for the structured inputs such as JSON. path consists of the names or the aliases of the commands, e.g. ["mod", "edit"].
+//
+// A flag value is a string, a number or a bool, or a slice of them to specify the flag repeatedly.
+// The flags must be accepted by the command, including the persistent flags of the parents.
+// The positional arguments are never expanded as response files.
+func (c *Root) CommandLine(path []string, flags map[string]any, args []string) ([]string, error) {
+	var cmd Command = c
+	for _, name := range path {
+		var sub Command
+		if p, ok := cmd.(ParentCommand); ok {
+			sub = findCommand(p, name)
+		}
+		if sub == nil {
+			return nil, fmt.Errorf("unknown command (%s)", strings.Join(path, " "))
+		}
+		cmd = sub
+	}
+
+	cmdLine := append([]string{}, path...)
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		if lookupFlag(cmd, name) == nil {
+			return nil, fmt.Errorf("unknown flag --%s for %s", name, strings.Join(FullName(cmd), " "))
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		values, err := flagValues(flags[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of --%s: %w", name, err)
+		}
+		for _, v := range values {
+			cmdLine = append(cmdLine, fmt.Sprintf("--%s=%s", name, v))
+		}
+	}
+
+	if len(args) > 0 {
+		cmdLine = append(cmdLine, "--")
+		for _, arg := range args {
+			if c.responseFiles && strings.HasPrefix(arg, "@") {
+				arg = "@" + arg
+			}
+			cmdLine = append(cmdLine, arg)
+		}
+	}
+	return cmdLine, nil
+}
+
+// lookupFlag returns the flag of the name accepted by c, or nil.
+// It only reads the flag sets, so it can be called concurrently.
+func lookupFlag(c Command, name string) *pflag.Flag {
+	if v, ok := c.(FlagSetSupported); ok {
+		if f := v.FS().Lookup(name); f != nil {
+			return f
+		}
+	}
+	for p := c; p != nil; {
+		if v, ok := p.(PersistentFlagSetSupported); ok {
+			if f := v.PersistentFS().Lookup(name); f != nil {
+				return f
+			}
+		}
+		sub, ok := p.(SubCommand)
+		if !ok {
+			break
+		}
+		p = sub.Parent()
+	}
+	return nil
+}
+
+// flagValues returns the values of a flag in the structured input as strings.
+func flagValues(v any) ([]string, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case bool:
+		return []string{strconv.FormatBool(v)}, nil
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}, nil
+	case int:
+		return []string{strconv.Itoa(v)}, nil
+	case []string:
+		return v, nil
+	case []any:
+		values := []string{}
+		for _, e := range v {
+			if _, ok := e.([]any); ok {
+				return nil, fmt.Errorf("nested array is not supported")
+			}
+			ev, err := flagValues(e)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, ev...)
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("unsupported type %T", v)
+}
@@ -0,0 +1,133 @@
+package mycmd
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// LogFormat is the format of the log.
+type LogFormat string
+
+const (
+	LogFormatText LogFormat = "text"
+	LogFormatJSON LogFormat = "json"
+)
+
+type loggerContextKey struct{}
+
+// ContextWithLogger returns a copy of ctx which holds the logger.
+func ContextWithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// LoggerFromContext returns the logger held by ctx.
+// If ctx has no logger, slog.Default() is returned.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerContextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// logConfig holds the values of the log flags defined by Root.EnableLogging.
+type logConfig struct {
+	verbose *int
+	quiet   *bool
+	level   *string
+	format  *string
+}
+
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// Level returns the log level. --log-level takes precedence over --quiet and -v.
+func (cfg *logConfig) Level() slog.Level {
+	if cfg == nil {
+		return slog.LevelWarn
+	}
+	if level, ok := logLevels[*cfg.level]; ok {
+		return level
+	}
+	if *cfg.quiet {
+		return slog.LevelError
+	}
+	switch {
+	case *cfg.verbose >= 2:
+		return slog.LevelDebug
+	case *cfg.verbose == 1:
+		return slog.LevelInfo
+	}
+	return slog.LevelWarn
+}
+
+func (cfg *logConfig) Format() LogFormat {
+	if cfg == nil || *cfg.format == "" {
+		return LogFormatText
+	}
+	return LogFormat(*cfg.format)
+}
+
+func (cfg *logConfig) newHandler(w io.Writer) slog.Handler {
+	opts := &slog.HandlerOptions{
+		Level: cfg.Level(),
+	}
+	if cfg.Format() == LogFormatJSON {
+		return slog.NewJSONHandler(w, opts)
+	}
+	// time is omitted in the text format, which is mainly read by the human on the terminal.
+	opts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+		if len(groups) == 0 && a.Key == slog.TimeKey {
+			return slog.Attr{}
+		}
+		return a
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// logConfigProvider is implemented by the command which configures the logger of its descendants.
+type logConfigProvider interface {
+	logConfig() *logConfig
+}
+
+func logConfigOf(c Command) *logConfig {
+	for p := c; p != nil; {
+		if v, ok := p.(logConfigProvider); ok {
+			if cfg := v.logConfig(); cfg != nil {
+				return cfg
+			}
+		}
+		sub, ok := p.(SubCommand)
+		if !ok {
+			break
+		}
+		p = sub.Parent()
+	}
+	return nil
+}
+
+// Logger returns the logger which writes to ErrWriter with the full name of the command as the "command" attribute.
+// The level and the format are configured by the flags defined by Root.EnableLogging.
+// Without them, the logger writes warnings and errors in the text format.
+func (c *Base) Logger() *slog.Logger {
+	w := writerFunc(func(p []byte) (n int, err error) {
+		c.withProgressPaused(func() {
+			n, err = c.errWriter.Write(p)
+		})
+		return n, err
+	})
+	return slog.New(logConfigOf(c).newHandler(w)).With(
+		slog.String("command", strings.Join(FullName(c), " ")),
+	)
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
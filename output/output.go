@@ -0,0 +1,145 @@
+// Package output provides the standard --output flag and renders values in the selected format.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	fv "github.com/kmio11/flag-validator/pflag-validator"
+	"github.com/kmio11/mycmd/wflag"
+)
+
+// Format is the name of an output format.
+type Format string
+
+const (
+	// Text is the human readable format implemented by each command.
+	Text Format = "text"
+	// JSON renders the value as indented JSON.
+	JSON Format = "json"
+	// YAML renders the value as YAML.
+	YAML Format = "yaml"
+	// Table renders the value as an aligned table.
+	Table Format = "table"
+	// Template renders the value with the Go text/template given as "template=<template>".
+	Template Format = "template"
+)
+
+var formats = []Format{Text, JSON, YAML, Table, Template}
+
+// Config configures the flags added by AddFlags.
+type Config struct {
+	// Shorthand of --output. Defaults to "o". Set "-" not to use a shorthand.
+	Shorthand string
+	// Default format. Defaults to Text.
+	Default Format
+}
+
+// Options holds the output options specified by the flags.
+type Options struct {
+	output    *string
+	columns   *[]string
+	noHeaders *bool
+}
+
+// AddFlags adds --output, --columns and --no-headers to fs.
+func AddFlags(fs *wflag.FlagSet, cfg Config) *Options {
+	shorthand := cfg.Shorthand
+	switch shorthand {
+	case "":
+		shorthand = "o"
+	case "-":
+		shorthand = ""
+	}
+	def := cfg.Default
+	if def == "" {
+		def = Text
+	}
+
+	names := []string{}
+	for _, f := range formats {
+		names = append(names, string(f))
+	}
+
+	o := &Options{
+		output: fs.StringP("output", shorthand, string(def),
+			fmt.Sprintf("output format (%s=<go template>)", strings.Join(names, "|")),
+		),
+		columns:   fs.StringSlice("columns", nil, "columns to show in the table format"),
+		noHeaders: fs.Bool("no-headers", false, "do not print headers in the table format"),
+	}
+
+	fs.AddValidationRules(
+		fv.ValueOf("output").Is(func(value string) bool {
+			_, _, err := parse(value)
+			return err == nil
+		}).Error(fmt.Sprintf("output format must be one of %s", strings.Join(names, ", "))),
+	)
+
+	return o
+}
+
+// Format returns the selected format.
+func (o *Options) Format() Format {
+	f, _, _ := parse(*o.output)
+	return f
+}
+
+func parse(value string) (Format, string, error) {
+	name, tmpl, hasTmpl := strings.Cut(value, "=")
+	f := Format(name)
+	switch f {
+	case Template:
+		if !hasTmpl || tmpl == "" {
+			return "", "", fmt.Errorf("template is not specified")
+		}
+		return f, tmpl, nil
+	case Text, JSON, YAML, Table:
+		if hasTmpl {
+			return "", "", fmt.Errorf("unknown output format (%s)", value)
+		}
+		return f, "", nil
+	}
+	return "", "", fmt.Errorf("unknown output format (%s)", value)
+}
+
+// Print writes v to w in the selected format.
+// text writes the human readable representation, which is used for the Text format.
+// If text is nil, v is printed with fmt.Fprintln.
+func (o *Options) Print(w io.Writer, v any, text func(w io.Writer) error) error {
+	f, tmpl, err := parse(*o.output)
+	if err != nil {
+		return err
+	}
+
+	switch f {
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case YAML:
+		return writeYAML(w, v)
+	case Table:
+		return writeTable(w, v, *o.columns, !*o.noHeaders)
+	case Template:
+		t, err := template.New("output").Funcs(template.FuncMap{
+			"json": func(v any) (string, error) {
+				b, err := json.Marshal(v)
+				return string(b), err
+			},
+		}).Parse(tmpl)
+		if err != nil {
+			return err
+		}
+		return t.Execute(w, v)
+	}
+
+	if text == nil {
+		_, err := fmt.Fprintln(w, v)
+		return err
+	}
+	return text(w)
+}
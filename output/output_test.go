@@ -0,0 +1,250 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/kmio11/mycmd/wflag"
+	flag "github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+type meta struct {
+	OS   string `json:"os"`
+	Arch string `json:"arch"`
+}
+
+type item struct {
+	Name    string   `json:"name"`
+	Version string   `json:"version"`
+	Tags    []string `json:"tags"`
+	Meta    meta     `json:"meta"`
+}
+
+var (
+	items = []item{
+		{Name: "mycmd", Version: "1.0.0", Tags: []string{"cli", "go"}, Meta: meta{OS: "linux", Arch: "amd64"}},
+		{Name: "tool", Version: "0.2.1", Meta: meta{OS: "darwin", Arch: "arm64"}},
+	}
+	counts = map[string]int{"b": 2, "a": 1}
+	nested = item{Name: "mycmd", Version: "1.0.0", Tags: []string{"cli"}, Meta: meta{OS: "linux", Arch: "amd64"}}
+)
+
+func TestOptions_Print(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		value   any
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "text",
+			args:  []string{},
+			value: nested,
+			want:  "text of mycmd\n",
+		},
+		{
+			name:  "json slice",
+			args:  []string{"-o", "json"},
+			value: items[1:],
+			want: `[
+  {
+    "name": "tool",
+    "version": "0.2.1",
+    "tags": null,
+    "meta": {
+      "os": "darwin",
+      "arch": "arm64"
+    }
+  }
+]
+`,
+		},
+		{
+			name:  "json map",
+			args:  []string{"-o", "json"},
+			value: counts,
+			want:  "{\n  \"a\": 1,\n  \"b\": 2\n}\n",
+		},
+		{
+			name:  "yaml slice",
+			args:  []string{"-o", "yaml"},
+			value: items,
+			want: `- name: mycmd
+  version: 1.0.0
+  tags:
+    - cli
+    - go
+  meta:
+    os: linux
+    arch: amd64
+- name: tool
+  version: 0.2.1
+  tags: null
+  meta:
+    os: darwin
+    arch: arm64
+`,
+		},
+		{
+			name:  "yaml map",
+			args:  []string{"-o", "yaml"},
+			value: counts,
+			want:  "a: 1\nb: 2\n",
+		},
+		{
+			name:  "table slice",
+			args:  []string{"-o", "table"},
+			value: items,
+			want: "NAME    VERSION   TAGS     META\n" +
+				"mycmd   1.0.0     cli,go   os=linux,arch=amd64\n" +
+				"tool    0.2.1              os=darwin,arch=arm64\n",
+		},
+		{
+			name:  "table map",
+			args:  []string{"-o", "table"},
+			value: counts,
+			want:  "A   B\n1   2\n",
+		},
+		{
+			name:  "table nested struct",
+			args:  []string{"-o", "table"},
+			value: nested,
+			want: "NAME    VERSION   TAGS   META\n" +
+				"mycmd   1.0.0     cli    os=linux,arch=amd64\n",
+		},
+		{
+			name:  "table slice of scalars",
+			args:  []string{"-o", "table"},
+			value: []string{"x", "y"},
+			want:  "VALUE\nx\ny\n",
+		},
+		{
+			name:  "table columns",
+			args:  []string{"-o", "table", "--columns", "Version,name"},
+			value: items,
+			want:  "VERSION   NAME\n1.0.0     mycmd\n0.2.1     tool\n",
+		},
+		{
+			name:  "table no headers",
+			args:  []string{"-o", "table", "--columns", "name", "--no-headers"},
+			value: items,
+			want:  "mycmd\ntool\n",
+		},
+		{
+			name:    "table unknown column",
+			args:    []string{"-o", "table", "--columns", "name,size"},
+			value:   items,
+			wantErr: "unknown column (size)",
+		},
+		{
+			name:  "template slice",
+			args:  []string{"-o", "template={{range .}}{{.Name}}@{{.Version}} {{end}}"},
+			value: items,
+			want:  "mycmd@1.0.0 tool@0.2.1 ",
+		},
+		{
+			name:  "template map",
+			args:  []string{"-o", "template={{.a}}+{{.b}}"},
+			value: counts,
+			want:  "1+2",
+		},
+		{
+			name:  "template nested struct with json",
+			args:  []string{"-o", "template={{json .Meta}}"},
+			value: nested,
+			want:  `{"os":"linux","arch":"amd64"}`,
+		},
+		{
+			name:    "template parse error",
+			args:    []string{"-o", "template={{.Name"},
+			value:   nested,
+			wantErr: "template: output:1: unclosed action",
+		},
+		{
+			name:    "template execution error",
+			args:    []string{"-o", "template={{.Size}}"},
+			value:   nested,
+			wantErr: `template: output:1:2: executing "output" at <.Size>: can't evaluate field Size in type output.item`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := wflag.NewFlagSet("test", flag.ContinueOnError)
+			o := AddFlags(fs, Config{})
+			assert.NoError(t, fs.Parse(tt.args))
+
+			var buf bytes.Buffer
+			err := o.Print(&buf, tt.value, func(w io.Writer) error {
+				_, err := fmt.Fprintf(w, "text of %s\n", tt.value.(item).Name)
+				return err
+			})
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestAddFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        Config
+		args       []string
+		wantFormat Format
+		wantErr    string
+	}{
+		{
+			name:       "default",
+			args:       []string{},
+			wantFormat: Text,
+		},
+		{
+			name:       "configured default",
+			cfg:        Config{Default: JSON},
+			args:       []string{},
+			wantFormat: JSON,
+		},
+		{
+			name:       "shorthand",
+			cfg:        Config{Shorthand: "f"},
+			args:       []string{"-f", "yaml"},
+			wantFormat: YAML,
+		},
+		{
+			name:    "no shorthand",
+			cfg:     Config{Shorthand: "-"},
+			args:    []string{"-o", "yaml"},
+			wantErr: "unknown shorthand flag: 'o' in -o",
+		},
+		{
+			name:    "unknown format",
+			args:    []string{"-o", "xml"},
+			wantErr: "output format must be one of text, json, yaml, table, template",
+		},
+		{
+			name:    "template is not specified",
+			args:    []string{"-o", "template"},
+			wantErr: "output format must be one of text, json, yaml, table, template",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := wflag.NewFlagSet("test", flag.ContinueOnError)
+			o := AddFlags(fs, tt.cfg)
+			err := fs.Parse(tt.args)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantFormat, o.Format())
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// writeTable writes v as a table.
+// A slice is rendered as rows, and a struct or a map is rendered as a row.
+func writeTable(w io.Writer, v any, columns []string, headers bool) error {
+	node, err := toNode(v)
+	if err != nil {
+		return err
+	}
+
+	rows := []*yaml.Node{node}
+	if node.Kind == yaml.SequenceNode {
+		rows = node.Content
+	}
+
+	// the columns appear in the order of the fields.
+	names := []string{}
+	seen := map[string]bool{}
+	for _, row := range rows {
+		if row.Kind != yaml.MappingNode {
+			if !seen["value"] {
+				names = append(names, "value")
+				seen["value"] = true
+			}
+			continue
+		}
+		for i := 0; i < len(row.Content); i += 2 {
+			name := row.Content[i].Value
+			if !seen[name] {
+				names = append(names, name)
+				seen[name] = true
+			}
+		}
+	}
+
+	if len(columns) > 0 {
+		selected := []string{}
+		for _, col := range columns {
+			found := false
+			for _, name := range names {
+				if strings.EqualFold(col, name) {
+					selected = append(selected, name)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("unknown column (%s)", col)
+			}
+		}
+		names = selected
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 3, ' ', 0)
+	if headers {
+		header := []string{}
+		for _, name := range names {
+			header = append(header, strings.ToUpper(name))
+		}
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+	}
+	for _, row := range rows {
+		cells := []string{}
+		for _, name := range names {
+			cells = append(cells, cell(row, name))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+func cell(row *yaml.Node, name string) string {
+	if row.Kind != yaml.MappingNode {
+		if name == "value" {
+			return nodeString(row)
+		}
+		return ""
+	}
+	for i := 0; i < len(row.Content); i += 2 {
+		if row.Content[i].Value == name {
+			return nodeString(row.Content[i+1])
+		}
+	}
+	return ""
+}
+
+// nodeString returns the compact representation of n.
+func nodeString(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.SequenceNode:
+		values := []string{}
+		for _, c := range n.Content {
+			values = append(values, nodeString(c))
+		}
+		return strings.Join(values, ",")
+	case yaml.MappingNode:
+		values := []string{}
+		for i := 0; i < len(n.Content); i += 2 {
+			values = append(values, fmt.Sprintf("%s=%s", n.Content[i].Value, nodeString(n.Content[i+1])))
+		}
+		return strings.Join(values, ",")
+	}
+	if n.Tag == "!!null" {
+		return ""
+	}
+	return n.Value
+}
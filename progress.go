@@ -0,0 +1,241 @@
+package mycmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kmio11/mycmd/internal/term"
+)
+
+const (
+	progressRedrawInterval = 100 * time.Millisecond
+	progressPlainInterval  = 2 * time.Second
+	progressBarWidth       = 30
+)
+
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// Progress shows the progress of a long-running process on ErrWriter.
+// When ErrWriter is a terminal, it is redrawn in place. Otherwise, plain-text lines are written periodically.
+// If total is 0, it is shown as a spinner, otherwise as a progress bar.
+type Progress struct {
+	mu      sync.Mutex
+	w       io.Writer
+	tty     bool
+	message string
+	total   int64
+	current int64
+
+	frame   int
+	drawn   bool
+	changed bool
+	stopped bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// StartProgress starts showing the progress on ErrWriter.
+// It is stopped by Progress.Stop, when ctx is done, or when the command returns from Execute.
+// Only one progress can be shown at a time, so the previous one is stopped.
+func (c *Base) StartProgress(ctx context.Context, message string, total int64) *Progress {
+	c.stopProgress()
+
+	p := newProgress(c.errWriter, term.IsTerminalWriter(c.errWriter), message, total)
+	c.progress = p
+
+	interval := progressPlainInterval
+	if p.tty {
+		interval = progressRedrawInterval
+	}
+	p.start(ctx, interval)
+	return p
+}
+
+func newProgress(w io.Writer, tty bool, message string, total int64) *Progress {
+	return &Progress{
+		w:       w,
+		tty:     tty,
+		message: message,
+		total:   total,
+		done:    make(chan struct{}),
+	}
+}
+
+// start draws the progress, then redraws it every interval until it is stopped or ctx is done.
+func (p *Progress) start(ctx context.Context, interval time.Duration) {
+	p.mu.Lock()
+	p.render()
+	p.mu.Unlock()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.done:
+				return
+			case <-ctx.Done():
+				go p.Stop()
+				return
+			case <-ticker.C:
+				p.tick()
+			}
+		}
+	}()
+}
+
+// tick advances the spinner and redraws the progress if needed.
+func (p *Progress) tick() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.frame++
+	if p.tty || p.changed {
+		p.render()
+	}
+}
+
+// SetMessage changes the message.
+func (p *Progress) SetMessage(message string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.message == message {
+		return
+	}
+	p.message = message
+	// the new message is shown immediately even in plain-text mode.
+	p.render()
+}
+
+// Add adds n to the current count.
+func (p *Progress) Add(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.current += n
+	p.changed = true
+}
+
+// SetCurrent sets the current count.
+func (p *Progress) SetCurrent(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.current = n
+	p.changed = true
+}
+
+// Stop stops showing the progress. It is safe to call Stop more than once.
+func (p *Progress) Stop() {
+	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		return
+	}
+	p.stopped = true
+	close(p.done)
+	p.mu.Unlock()
+
+	p.wg.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.tty {
+		p.clear()
+		return
+	}
+	if p.total > 0 && p.changed {
+		p.render()
+	}
+}
+
+// pause clears the progress while f writes something, then draws it again.
+func (p *Progress) pause(f func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+	f()
+	if p.tty && !p.stopped {
+		p.render()
+	}
+}
+
+func (p *Progress) clear() {
+	if p.tty && p.drawn {
+		fmt.Fprint(p.w, "\r\x1b[K")
+		p.drawn = false
+	}
+}
+
+// render writes the progress. The caller must hold the lock.
+func (p *Progress) render() {
+	if p.tty {
+		if p.stopped {
+			return
+		}
+		fmt.Fprintf(p.w, "\r\x1b[K%s", p.status())
+		p.drawn = true
+		return
+	}
+	fmt.Fprintln(p.w, p.status())
+	p.changed = false
+}
+
+func (p *Progress) status() string {
+	if p.total <= 0 {
+		if !p.tty {
+			if p.current > 0 {
+				return fmt.Sprintf("%s... (%d)", p.message, p.current)
+			}
+			return fmt.Sprintf("%s...", p.message)
+		}
+		return fmt.Sprintf("%s %s", spinnerFrames[p.frame%len(spinnerFrames)], p.message)
+	}
+
+	current := p.current
+	if current > p.total {
+		current = p.total
+	}
+	percent := current * 100 / p.total
+	if !p.tty {
+		return fmt.Sprintf("%s: %d/%d (%d%%)", p.message, current, p.total, percent)
+	}
+	filled := int(current * progressBarWidth / p.total)
+	bar := strings.Repeat("=", filled)
+	if filled < progressBarWidth {
+		bar += ">" + strings.Repeat(" ", progressBarWidth-filled-1)
+	}
+	return fmt.Sprintf("[%s] %3d%% %s (%d/%d)", bar, percent, p.message, current, p.total)
+}
+
+// stopProgress stops the progress started by StartProgress, if any.
+func (c *Base) stopProgress() {
+	if c.progress != nil {
+		c.progress.Stop()
+		c.progress = nil
+	}
+}
+
+// withProgressPaused calls f while the progress is cleared.
+func (c *Base) withProgressPaused(f func()) {
+	if c.progress == nil {
+		f()
+		return
+	}
+	c.progress.pause(f)
+}
+
+// progressStopper is implemented by the commands which can show a progress.
+type progressStopper interface {
+	stopProgress()
+}
+
+func stopProgress(c Command) {
+	if v, ok := c.(progressStopper); ok {
+		v.stopProgress()
+	}
+}
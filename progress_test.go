@@ -0,0 +1,151 @@
+package mycmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTerminal records what is written to the terminal.
+type fakeTerminal struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (t *fakeTerminal) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.buf.Write(p)
+}
+
+// take returns the written output and discards it.
+func (t *fakeTerminal) take() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.buf.String()
+	t.buf.Reset()
+	return s
+}
+
+// startTestProgress starts p without redrawing it by the ticker, so that the test calls tick instead.
+func startTestProgress(t *testing.T, ctx context.Context, tty bool, message string, total int64) (*Progress, *fakeTerminal) {
+	t.Helper()
+	term := &fakeTerminal{}
+	p := newProgress(term, tty, message, total)
+	p.start(ctx, time.Hour)
+	t.Cleanup(p.Stop)
+	return p, term
+}
+
+func TestProgress_bar(t *testing.T) {
+	p, term := startTestProgress(t, context.Background(), true, "copy", 10)
+	assert.Equal(t, "\r\x1b[K[>"+strings.Repeat(" ", 29)+"]   0% copy (0/10)", term.take())
+
+	p.SetCurrent(5)
+	assert.Equal(t, "", term.take(), "the count is drawn by the next tick")
+	p.tick()
+	assert.Equal(t, "\r\x1b[K["+strings.Repeat("=", 15)+">"+strings.Repeat(" ", 14)+"]  50% copy (5/10)", term.take())
+
+	p.Add(10)
+	p.tick()
+	assert.Equal(t, "\r\x1b[K["+strings.Repeat("=", 30)+"] 100% copy (10/10)", term.take(), "the count is capped by total")
+
+	p.SetMessage("done")
+	assert.Equal(t, "\r\x1b[K["+strings.Repeat("=", 30)+"] 100% done (10/10)", term.take(), "the message is drawn immediately")
+
+	p.Stop()
+	assert.Equal(t, "\r\x1b[K", term.take(), "the bar is cleared")
+	p.Stop()
+	p.tick()
+	assert.Equal(t, "", term.take(), "nothing is drawn after Stop")
+}
+
+func TestProgress_spinner(t *testing.T) {
+	p, term := startTestProgress(t, context.Background(), true, "waiting", 0)
+	assert.Equal(t, "\r\x1b[K| waiting", term.take())
+
+	for _, frame := range []string{"/", "-", "\\", "|"} {
+		p.tick()
+		assert.Equal(t, "\r\x1b[K"+frame+" waiting", term.take())
+	}
+
+	p.Stop()
+	assert.Equal(t, "\r\x1b[K", term.take())
+}
+
+func TestProgress_plain(t *testing.T) {
+	t.Run("bar", func(t *testing.T) {
+		p, term := startTestProgress(t, context.Background(), false, "copy", 4)
+		assert.Equal(t, "copy: 0/4 (0%)\n", term.take())
+
+		p.tick()
+		assert.Equal(t, "", term.take(), "unchanged progress is not written again")
+
+		p.Add(1)
+		p.tick()
+		assert.Equal(t, "copy: 1/4 (25%)\n", term.take())
+
+		p.Add(1)
+		p.Stop()
+		assert.Equal(t, "copy: 2/4 (50%)\n", term.take(), "the last count is written by Stop")
+	})
+
+	t.Run("spinner", func(t *testing.T) {
+		p, term := startTestProgress(t, context.Background(), false, "waiting", 0)
+		assert.Equal(t, "waiting...\n", term.take())
+
+		p.Add(3)
+		p.tick()
+		assert.Equal(t, "waiting... (3)\n", term.take())
+
+		p.Stop()
+		assert.Equal(t, "", term.take())
+	})
+}
+
+func TestProgress_contextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p, term := startTestProgress(t, ctx, true, "waiting", 0)
+	assert.Equal(t, "\r\x1b[K| waiting", term.take())
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return p.stopped && !p.drawn
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, "\r\x1b[K", term.take())
+}
+
+func TestBase_Print_withProgress(t *testing.T) {
+	term := &fakeTerminal{}
+	c := NewBase("test", BaseConfig{})
+	c.SetOutWriter(term)
+	c.SetErrWriter(term)
+
+	c.Print("no progress\n")
+	assert.Equal(t, "no progress\n", term.take())
+
+	p := newProgress(term, true, "waiting", 0)
+	c.progress = p
+	p.start(context.Background(), time.Hour)
+	assert.Equal(t, "\r\x1b[K| waiting", term.take())
+
+	c.Print("hello\n")
+	assert.Equal(t, "\r\x1b[Khello\n\r\x1b[K| waiting", term.take(), "the progress is cleared while printing, then drawn again")
+
+	c.PrintError("oops\n")
+	assert.Equal(t, "\r\x1b[Koops\n\r\x1b[K| waiting", term.take())
+
+	c.stopProgress()
+	assert.Equal(t, "\r\x1b[K", term.take())
+	assert.Nil(t, c.progress)
+
+	c.Print("after\n")
+	assert.Equal(t, "after\n", term.take())
+}
@@ -1,11 +1,32 @@
 package mycmd
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/kmio11/mycmd/internal/term"
+	"github.com/kmio11/mycmd/wflag"
+)
 
 var _ ParentCommand = (*Root)(nil)
 
+// Root is the root of a command tree.
+// The commands of the tree hold the state of an invocation, e.g. the parsed subcommand, the flag values and the shell history,
+// so a Root is not safe for concurrent use: its invocations are serialized, and each of them resets the tree before parsing.
+// Use Invoker to run the invocations concurrently, each on its own tree.
 type Root struct {
 	*ParentBase
+	noInput       *bool
+	log           *logConfig
+	responseFiles bool
+	debugFlags    *bool
+
+	instrumentation Instrumentation
+
+	// mu serializes the invocations.
+	mu sync.Mutex
 }
 
 func NewRoot(name string) *Root {
@@ -17,15 +38,114 @@ func NewRoot(name string) *Root {
 
 func (c *Root) AddCommands(commands ...Command) *Root {
 	c.ParentBase = c.ParentBase.AddCommands(commands...)
+	for _, command := range commands {
+		if sub, ok := command.(SubCommand); ok {
+			sub.SetParent(c)
+		}
+	}
+	return c
+}
+
+// AddHelpTopics adds help topics, which are shown by the help command but are not executable.
+func (c *Root) AddHelpTopics(topics ...HelpTopic) *Root {
+	c.ParentBase = c.ParentBase.AddHelpTopics(topics...)
+	return c
+}
+
+// EnablePrompt enables to prompt for the missing required flags and arguments.
+// The prompt is shown only when the input reader of the command is a terminal,
+// and can be disabled by the --no-input flag.
+func (c *Root) EnablePrompt() *Root {
+	c.noInput = c.PersistentFS().Bool("no-input", false, "disable interactive prompts")
+	return c
+}
+
+func (c *Root) prompter(inReader io.Reader, errWriter io.Writer) *wflag.Prompter {
+	if c.noInput == nil {
+		return nil
+	}
+	return &wflag.Prompter{
+		In:  inReader,
+		Out: errWriter,
+		Enabled: func() bool {
+			return !*c.noInput && term.IsTerminalReader(inReader)
+		},
+	}
+}
+
+// EnableLogging adds the flags configuring the logger returned by Base.Logger:
+// -v/--verbose (repeatable), -q/--quiet, --log-level and --log-format.
+func (c *Root) EnableLogging() *Root {
+	fs := c.PersistentFS()
+	c.log = &logConfig{
+		verbose: fs.CountP("verbose", "v", "increase the log verbosity (-v: info, -vv: debug)"),
+		quiet:   fs.BoolP("quiet", "q", false, "log errors only"),
+		level: fs.Enum("log-level", "", []string{"debug", "info", "warn", "error"},
+			"log level (debug|info|warn|error), which takes precedence over -v and -q",
+		),
+		format: fs.Enum("log-format", string(LogFormatText), []string{string(LogFormatText), string(LogFormatJSON)},
+			"log format (text|json)",
+		),
+	}
+	return c
+}
+
+func (c *Root) logConfig() *logConfig {
+	return c.log
+}
+
+// EnableResponseFiles enables to expand the arguments of the form @path into the arguments in the file.
+// See ExpandResponseFiles for the details.
+func (c *Root) EnableResponseFiles() *Root {
+	c.responseFiles = true
+	return c
+}
+
+func (c *Root) expandArgs(args []string) ([]string, error) {
+	if !c.responseFiles {
+		return args, nil
+	}
+	return ExpandResponseFiles(args)
+}
+
+// EnableDebugFlags adds the hidden --debug-flags flag, which prints the table of the effective values
+// and the sources of the flags and the arguments of the command to ErrWriter before executing it.
+func (c *Root) EnableDebugFlags() *Root {
+	c.debugFlags = c.PersistentFS().Bool("debug-flags", false, "print the values and the sources of the flags before executing the command")
+	_ = c.PersistentFS().MarkHidden("debug-flags")
 	return c
 }
 
+func (c *Root) preExecute() {
+	if c.debugFlags == nil || !*c.debugFlags {
+		return
+	}
+	c.PrintError(flagSources(parsedLeaf(c)))
+}
+
 // ParseAndExecute parses and executes command.
+// The tree is reset before parsing, so the same Root can be run repeatedly.
+// The concurrent calls are serialized. Use Invoker to run the commands concurrently.
 func (c *Root) ParseAndExecute(args []string) int {
-	return RunCommand(c, args)
+	return c.invoke(context.Background(), args, execute).ExitCode
 }
 
 // ParseAndExecuteContext parses and executes command with context.
 func (c *Root) ParseAndExecuteContext(ctx context.Context, args []string) int {
-	return RunCommandContext(ctx, c, args)
+	return c.Invoke(ctx, args).ExitCode
+}
+
+// Invoke is the same as ParseAndExecuteContext, but returns the Invocation holding the result.
+func (c *Root) Invoke(ctx context.Context, args []string) *Invocation {
+	return c.invoke(ctx, args, executeContext)
+}
+
+// validateInDebugBuild panics if the tree is invalid. It does nothing unless built with the mycmd_debug tag.
+func (c *Root) validateInDebugBuild() {
+	if !debugBuild {
+		return
+	}
+	if err := Validate(c); err != nil {
+		panic(fmt.Errorf("invalid command tree of %s:\n%w", c.Name(), err))
+	}
 }
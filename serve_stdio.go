@@ -0,0 +1,442 @@
+package mycmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kmio11/mycmd/internal/syncbuf"
+	"github.com/kmio11/mycmd/wflag"
+	"github.com/spf13/pflag"
+)
+
+const serveStdioName = "serve-stdio"
+
+// EnableServeStdio adds the hidden serve-stdio command, which serves the commands of c with JSON-RPC 2.0
+// over InReader and OutWriter. Each line of the input is a request, and each line of the output is a response.
+//
+//	list                   returns the commands with the JSON Schema of their input:
+//	                       {"commands": [{"name": "mod edit", "description": ..., "inputSchema": ...}]}
+//	call                   runs the command with {"name": "mod edit", "flags": {...}, "args": [...], "stdin": "..."}
+//	                       and returns {"exitCode": 0, "stdout": "...", "stderr": "..."}
+//	$/cancelRequest        cancels the context of the call of {"id": ...}. It is a notification.
+//
+// Only the commands returned by list can be called, which are the leaf commands except the hidden, the deprecated
+// and the interactive ones, e.g. Shell. They are called by their full names, not by the aliases.
+//
+// The calls are run concurrently on the trees made by newRoot, which must return a new tree on each call.
+// The server stops at the end of the input after the running calls finish.
+// The batch requests are not supported.
+func (c *Root) EnableServeStdio(newRoot func() *Root) *Root {
+	return c.AddCommands(&stdioServer{
+		Base: NewBase(serveStdioName, BaseConfig{
+			ShortDescription: "serve the commands with JSON-RPC 2.0 over the standard input and output",
+			Hidden:           true,
+		}),
+		invoker: NewInvoker(newRoot),
+	})
+}
+
+// the error codes defined by JSON-RPC 2.0.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcCommand struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+type rpcCallParams struct {
+	Name  string         `json:"name"`
+	Flags map[string]any `json:"flags,omitempty"`
+	Args  []string       `json:"args,omitempty"`
+	Stdin string         `json:"stdin,omitempty"`
+}
+
+type rpcCallResult struct {
+	ExitCode int    `json:"exitCode"`
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+}
+
+type rpcCancelParams struct {
+	ID json.RawMessage `json:"id"`
+}
+
+var _ SubCommand = (*stdioServer)(nil)
+
+// stdioServer is the command added by EnableServeStdio.
+type stdioServer struct {
+	*Base
+	invoker *Invoker
+
+	// writeMu serializes the responses.
+	writeMu sync.Mutex
+	// calls are the cancel functions of the running calls by their IDs.
+	calls   map[string]context.CancelFunc
+	callsMu sync.Mutex
+	running sync.WaitGroup
+}
+
+func (c *stdioServer) Execute() int {
+	return c.ExecuteContext(context.Background())
+}
+
+// ExecuteContext serves until the end of the input. ctx is the parent of the contexts of the calls.
+func (c *stdioServer) ExecuteContext(ctx context.Context) int {
+	root, ok := c.Parent().(*Root)
+	if !ok {
+		c.PrintError(fmt.Sprintf("ERROR : %s must be a subcommand of Root\n", c.Name()))
+		return 1
+	}
+	c.calls = map[string]context.CancelFunc{}
+
+	scanner := bufio.NewScanner(c.InReader())
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		c.handle(ctx, root, []byte(line))
+	}
+	c.running.Wait()
+
+	if err := scanner.Err(); err != nil {
+		c.PrintError(fmt.Sprintf("ERROR : %s\n", err))
+		return 1
+	}
+	return 0
+}
+
+// handle handles a request. The calls are run in the background.
+func (c *stdioServer) handle(ctx context.Context, root *Root, line []byte) {
+	req := rpcRequest{}
+	if err := json.Unmarshal(line, &req); err != nil {
+		c.respondError(nil, &rpcError{Code: rpcParseError, Message: err.Error()})
+		return
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		c.respondError(req.ID, &rpcError{Code: rpcInvalidRequest, Message: `"jsonrpc" must be "2.0" and "method" is required`})
+		return
+	}
+	// the notifications are never responded, even if they fail.
+	if err := c.dispatch(ctx, root, req); err != nil && req.ID != nil {
+		c.respondError(req.ID, err)
+	}
+}
+
+func (c *stdioServer) dispatch(ctx context.Context, root *Root, req rpcRequest) *rpcError {
+	switch req.Method {
+	case "list":
+		c.respond(req.ID, map[string]any{"commands": listCommands(root)})
+	case "call":
+		params := rpcCallParams{}
+		if err := unmarshalParams(req.Params, &params); err != nil {
+			return &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+		path := strings.Fields(params.Name)
+		if !isCallable(root, strings.Join(path, " ")) {
+			return &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("unknown command (%s)", params.Name)}
+		}
+		args, err := root.CommandLine(path, params.Flags, params.Args)
+		if err != nil {
+			return &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+		return c.call(ctx, req.ID, args, params.Stdin)
+	case "$/cancelRequest":
+		params := rpcCancelParams{}
+		if err := unmarshalParams(req.Params, &params); err != nil {
+			return &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+		c.callsMu.Lock()
+		if cancel, ok := c.calls[string(params.ID)]; ok {
+			cancel()
+		}
+		c.callsMu.Unlock()
+	default:
+		return &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("method %q is not found", req.Method)}
+	}
+	return nil
+}
+
+// call runs args in the background with the context which is cancelled by $/cancelRequest of id.
+// The call is rejected if the call of the same id is running, since the calls are cancelled by their IDs.
+func (c *stdioServer) call(ctx context.Context, id json.RawMessage, args []string, stdin string) *rpcError {
+	ctx, cancel := context.WithCancel(ctx)
+	if id != nil {
+		c.callsMu.Lock()
+		if _, ok := c.calls[string(id)]; ok {
+			c.callsMu.Unlock()
+			cancel()
+			return &rpcError{Code: rpcInvalidRequest, Message: fmt.Sprintf("request id %s is already in flight", id)}
+		}
+		c.calls[string(id)] = cancel
+		c.callsMu.Unlock()
+	}
+
+	c.running.Add(1)
+	go func() {
+		defer c.running.Done()
+		defer func() {
+			c.callsMu.Lock()
+			delete(c.calls, string(id))
+			c.callsMu.Unlock()
+			cancel()
+		}()
+
+		var stdout, stderr syncbuf.Buffer
+		inv := c.invoker.Invoke(ctx, args, strings.NewReader(stdin), &stdout, &stderr)
+		c.respond(id, rpcCallResult{
+			ExitCode: inv.ExitCode,
+			Stdout:   stdout.String(),
+			Stderr:   stderr.String(),
+		})
+	}()
+	return nil
+}
+
+func unmarshalParams(params json.RawMessage, v any) error {
+	if len(params) == 0 {
+		return errors.New("params are required")
+	}
+	dec := json.NewDecoder(strings.NewReader(string(params)))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// respond writes the result of the request of id. Nothing is written for the notifications, which have no id.
+func (c *stdioServer) respond(id json.RawMessage, result any) {
+	if id == nil {
+		return
+	}
+	c.write(rpcResponse{JSONRPC: "2.0", ID: id, Result: result})
+}
+
+// respondError writes the error of the request of id. The id is null if it is unknown, e.g. for the parse errors.
+func (c *stdioServer) respondError(id json.RawMessage, err *rpcError) {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	c.write(rpcResponse{JSONRPC: "2.0", ID: id, Error: err})
+}
+
+func (c *stdioServer) write(res rpcResponse) {
+	data, err := json.Marshal(res)
+	if err != nil {
+		data, _ = json.Marshal(rpcResponse{JSONRPC: "2.0", ID: res.ID, Error: &rpcError{Code: rpcInternalError, Message: err.Error()}})
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.Print(string(data) + "\n")
+}
+
+// listCommands returns the commands of root which can be called, i.e. the visible commands which have no subcommands.
+func listCommands(root *Root) []rpcCommand {
+	commands := []rpcCommand{}
+	walkCallable(root, func(c Command, path []string, inherited []*wflag.FlagSet) {
+		commands = append(commands, rpcCommand{
+			Name:        strings.Join(path, " "),
+			Description: c.ShortDescription(),
+			InputSchema: inputSchema(c, inherited),
+		})
+	})
+	return commands
+}
+
+// isCallable reports whether name is the name of a command returned by list.
+func isCallable(root *Root, name string) bool {
+	found := false
+	walkCallable(root, func(c Command, path []string, inherited []*wflag.FlagSet) {
+		found = found || strings.Join(path, " ") == name
+	})
+	return found
+}
+
+// walkCallable calls f with the commands which can be called, and the persistent flag sets of their parents.
+// They are the leaf commands except the hidden, the deprecated and the interactive ones, e.g. Shell.
+func walkCallable(root *Root, f func(c Command, path []string, inherited []*wflag.FlagSet)) {
+	var walk func(c Command, path []string, inherited []*wflag.FlagSet)
+	walk = func(c Command, path []string, inherited []*wflag.FlagSet) {
+		if v, ok := c.(HiddenSupported); ok && v.Hidden() {
+			return
+		}
+		if v, ok := c.(DeprecatedSupported); ok && v.Deprecated() != "" {
+			return
+		}
+		if _, ok := c.(*Shell); ok {
+			return
+		}
+		if v, ok := c.(PersistentFlagSetSupported); ok {
+			inherited = append(inherited[:len(inherited):len(inherited)], v.PersistentFS())
+		}
+		if p, ok := c.(ParentCommand); ok {
+			for _, sub := range p.Commands() {
+				walk(sub, append(path[:len(path):len(path)], sub.Name()), inherited)
+			}
+			return
+		}
+		f(c, path, inherited)
+	}
+	walk(root, []string{}, nil)
+}
+
+// inputSchema returns the JSON Schema of the params of call for c, whose flags are derived from the flag sets of c
+// and the persistent flag sets of its parents in inherited.
+func inputSchema(c Command, inherited []*wflag.FlagSet) map[string]any {
+	// the own flags take precedence over the inherited ones like the parsing does.
+	sets := []*wflag.FlagSet{}
+	var args []wflag.Arg
+	if v, ok := c.(FlagSetSupported); ok {
+		sets = append(sets, v.FS())
+		args = v.FS().Arguments()
+	}
+	for i := len(inherited) - 1; i >= 0; i-- {
+		sets = append(sets, inherited[i])
+	}
+
+	flagProps := map[string]any{}
+	required := []string{}
+	for _, set := range sets {
+		set.VisitAll(func(f *pflag.Flag) {
+			if _, ok := flagProps[f.Name]; ok || f.Hidden {
+				return
+			}
+			flagProps[f.Name] = flagSchema(f)
+			if set.IsRequired(f.Name) {
+				required = append(required, f.Name)
+			}
+		})
+	}
+	flags := map[string]any{
+		"type":                 "object",
+		"properties":           flagProps,
+		"additionalProperties": false,
+	}
+
+	prefixItems := []any{}
+	minItems := 0
+	for _, a := range args {
+		for len(prefixItems) < a.Index {
+			prefixItems = append(prefixItems, map[string]any{"type": "string"})
+		}
+		prefixItems = append(prefixItems, map[string]any{"type": "string", "title": a.Name, "description": a.Usage})
+		if a.Required {
+			minItems = a.Index + 1
+		}
+	}
+	argsSchema := map[string]any{
+		"type":  "array",
+		"items": map[string]any{"type": "string"},
+	}
+	if len(prefixItems) > 0 {
+		argsSchema["prefixItems"] = prefixItems
+	}
+	if minItems > 0 {
+		argsSchema["minItems"] = minItems
+	}
+
+	schema := map[string]any{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type":    "object",
+		"properties": map[string]any{
+			"flags": flags,
+			"args":  argsSchema,
+			"stdin": map[string]any{"type": "string"},
+		},
+		"additionalProperties": false,
+	}
+	requiredProps := []string{}
+	if len(required) > 0 {
+		flags["required"] = required
+		requiredProps = append(requiredProps, "flags")
+	}
+	if minItems > 0 {
+		requiredProps = append(requiredProps, "args")
+	}
+	if len(requiredProps) > 0 {
+		schema["required"] = requiredProps
+	}
+	return schema
+}
+
+// flagSchema returns the JSON Schema of the value of f, which is derived from the type of f.
+func flagSchema(f *pflag.Flag) map[string]any {
+	s := valueSchema(f.Value.Type())
+	if v, ok := f.Value.(interface{ Choices() []string }); ok {
+		s["enum"] = v.Choices()
+	}
+	if f.Usage != "" {
+		s["description"] = f.Usage
+	}
+	if def, ok := defaultValue(s["type"], f.DefValue); ok {
+		s["default"] = def
+	}
+	return s
+}
+
+// valueSchema returns the JSON Schema of the flag value of typ, e.g. "int" or "stringSlice".
+func valueSchema(typ string) map[string]any {
+	for _, suffix := range []string{"Slice", "Array"} {
+		if elem, ok := strings.CutSuffix(typ, suffix); ok {
+			return map[string]any{"type": "array", "items": valueSchema(elem)}
+		}
+	}
+	switch typ {
+	case "bool":
+		return map[string]any{"type": "boolean"}
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "count":
+		return map[string]any{"type": "integer"}
+	case "float32", "float64":
+		return map[string]any{"type": "number"}
+	}
+	return map[string]any{"type": "string"}
+}
+
+// defaultValue converts the default value of the flag into the JSON value of typ. The zero values are omitted.
+func defaultValue(typ any, def string) (any, bool) {
+	switch typ {
+	case "boolean":
+		v, err := strconv.ParseBool(def)
+		return v, err == nil && v
+	case "integer":
+		v, err := strconv.ParseInt(def, 10, 64)
+		return v, err == nil && v != 0
+	case "number":
+		v, err := strconv.ParseFloat(def, 64)
+		return v, err == nil && v != 0
+	case "string":
+		return def, def != ""
+	}
+	return nil, false
+}
@@ -0,0 +1,202 @@
+package mycmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kmio11/mycmd/wflag"
+	"github.com/stretchr/testify/assert"
+)
+
+type rpcTestResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+// serveStdio runs serve-stdio of root with the requests, and returns the responses in the order of writing.
+func serveStdio(t *testing.T, root *Root, requests ...string) []rpcTestResponse {
+	t.Helper()
+	outWriter, errWriter := new(bytes.Buffer), new(bytes.Buffer)
+	root.SetOutWriter(outWriter)
+	root.SetErrWriter(errWriter)
+	root.SetInReader(strings.NewReader(strings.Join(requests, "\n")))
+
+	assert.Equal(t, 0, root.ParseAndExecute([]string{serveStdioName}))
+	assert.Empty(t, errWriter.String())
+
+	responses := []rpcTestResponse{}
+	for _, line := range strings.Split(strings.TrimSuffix(outWriter.String(), "\n"), "\n") {
+		res := rpcTestResponse{}
+		if err := json.Unmarshal([]byte(line), &res); err != nil {
+			t.Fatal(err)
+		}
+		responses = append(responses, res)
+	}
+	return responses
+}
+
+// responsesByID returns the responses by their IDs.
+func responsesByID(responses []rpcTestResponse) map[string]rpcTestResponse {
+	m := map[string]rpcTestResponse{}
+	for _, res := range responses {
+		m[string(res.ID)] = res
+	}
+	return m
+}
+
+// newServeStdioTree returns the tree which has the callable commands and the ones which are not callable.
+func newServeStdioTree() *Root {
+	echo := func(name string, opts ...Option) *FuncCommand {
+		var upper *bool
+		opts = append(opts,
+			WithFlags(func(fs *wflag.FlagSet) {
+				upper = fs.Bool("upper", false, "print in upper case")
+				fs.ArgString(0, "message", "the message to print")
+			}),
+			WithRun(func(ctx context.Context, cmd *FuncCommand) error {
+				msg := strings.Join(cmd.FS().Args(), " ")
+				if *upper {
+					msg = strings.ToUpper(msg)
+				}
+				cmd.Print(msg)
+				return nil
+			}),
+		)
+		return MustNew(name, opts...)
+	}
+
+	root := NewRoot("app").AddCommands(
+		echo("echo", WithShort("print the message"), WithAliases("e")),
+		echo("secret", WithHidden()),
+		echo("old", WithDeprecated("use 'echo' instead")),
+		NewParentBase("group", BaseConfig{}).AddCommands(
+			echo("leaf"),
+		),
+		NewShell("shell", BaseConfig{}),
+	)
+	root.PersistentFS().Bool("verbose", false, "verbose output")
+	return root.EnableServeStdio(newServeStdioTree)
+}
+
+func TestServeStdio(t *testing.T) {
+	responses := responsesByID(serveStdio(t, newServeStdioTree(),
+		`{"jsonrpc": "2.0", "id": 1, "method": "list"}`,
+		`{"jsonrpc": "2.0", "id": 2, "method": "call", "params": {"name": "echo", "flags": {"upper": true}, "args": ["hello"]}}`,
+		`{"jsonrpc": "2.0", "id": "3", "method": "call", "params": {"name": "group leaf", "flags": {"upper": "yes"}}}`,
+		`{"jsonrpc": "2.0", "method": "call", "params": {"name": "echo"}}`,
+		`{"jsonrpc": "2.0", "id": 4, "method": "call", "params": {"name": "group unknown"}}`,
+		`{"jsonrpc": "2.0", "id": 5, "method": "unknown"}`,
+		`{"jsonrpc": "2.0", "id": 6, "method": "call", "params": {"name": "echo", "stdout": ""}}`,
+		`{"jsonrpc": "2.0", "id": 7, "method": "call", "params": {"name": "echo", "flags": {"race": true}}}`,
+		`{"jsonrpc": "2.0", "id": 8}`,
+		`not json`,
+	))
+	assert.Len(t, responses, 9)
+
+	list := struct {
+		Commands []rpcCommand `json:"commands"`
+	}{}
+	assert.NoError(t, json.Unmarshal(responses["1"].Result, &list))
+	names := []string{}
+	for _, c := range list.Commands {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"echo", "group leaf"}, names)
+	assert.Equal(t, "print the message", list.Commands[0].Description)
+	schema, err := json.Marshal(list.Commands[0].InputSchema)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {
+			"flags": {
+				"type": "object",
+				"properties": {
+					"upper": {"type": "boolean", "description": "print in upper case"},
+					"verbose": {"type": "boolean", "description": "verbose output"}
+				},
+				"additionalProperties": false
+			},
+			"args": {
+				"type": "array",
+				"items": {"type": "string"},
+				"prefixItems": [{"type": "string", "title": "message", "description": "the message to print"}]
+			},
+			"stdin": {"type": "string"}
+		},
+		"additionalProperties": false
+	}`, string(schema))
+
+	assert.JSONEq(t, `{"exitCode": 0, "stdout": "HELLO", "stderr": ""}`, string(responses["2"].Result))
+	assert.JSONEq(t, `{"exitCode": 2, "stdout": "", "stderr": "ERROR : invalid argument \"yes\" for \"--upper\" flag: `+
+		`strconv.ParseBool: parsing \"yes\": invalid syntax\nRun 'app group help leaf' for usage.\n"}`,
+		string(responses[`"3"`].Result))
+	assert.Equal(t, &rpcError{Code: rpcInvalidParams, Message: "unknown command (group unknown)"}, responses["4"].Error)
+	assert.Equal(t, rpcMethodNotFound, responses["5"].Error.Code)
+	assert.Equal(t, rpcInvalidParams, responses["6"].Error.Code)
+	assert.Equal(t, &rpcError{Code: rpcInvalidParams, Message: "unknown flag --race for app echo"}, responses["7"].Error)
+	assert.Equal(t, rpcInvalidRequest, responses["8"].Error.Code)
+	assert.Equal(t, rpcParseError, responses["null"].Error.Code)
+}
+
+func TestServeStdio_notListed(t *testing.T) {
+	names := []string{"secret", "old", "group", "shell", serveStdioName, "e", ""}
+	requests := []string{}
+	for i, name := range names {
+		requests = append(requests,
+			fmt.Sprintf(`{"jsonrpc": "2.0", "id": %d, "method": "call", "params": {"name": %q, "args": ["secret"]}}`, i, name),
+		)
+	}
+	responses := responsesByID(serveStdio(t, newServeStdioTree(), requests...))
+	assert.Len(t, responses, len(names))
+	for i, name := range names {
+		assert.Equal(t, &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("unknown command (%s)", name)},
+			responses[strconv.Itoa(i)].Error, name)
+	}
+}
+
+// newWaitTree returns the tree whose wait command waits for the cancellation.
+func newWaitTree() *Root {
+	return NewRoot("test").AddCommands(
+		MustNew("wait",
+			WithShort("wait for the cancellation"),
+			WithRun(func(ctx context.Context, cmd *FuncCommand) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}),
+		),
+	).EnableServeStdio(newWaitTree)
+}
+
+func TestServeStdio_cancel(t *testing.T) {
+	responses := serveStdio(t, newWaitTree(),
+		`{"jsonrpc": "2.0", "id": 1, "method": "call", "params": {"name": "wait"}}`,
+		`{"jsonrpc": "2.0", "id": 2, "method": "call", "params": {"name": "wait"}}`,
+		`{"jsonrpc": "2.0", "method": "$/cancelRequest", "params": {"id": 2}}`,
+		`{"jsonrpc": "2.0", "method": "$/cancelRequest", "params": {"id": 1}}`,
+	)
+	assert.Len(t, responses, 2)
+	for id, res := range responsesByID(responses) {
+		assert.JSONEq(t, `{"exitCode": 1, "stdout": "", "stderr": "ERROR : context canceled\n"}`, string(res.Result), id)
+	}
+}
+
+func TestServeStdio_duplicateID(t *testing.T) {
+	responses := serveStdio(t, newWaitTree(),
+		`{"jsonrpc": "2.0", "id": 1, "method": "call", "params": {"name": "wait"}}`,
+		`{"jsonrpc": "2.0", "id": 1, "method": "call", "params": {"name": "wait"}}`,
+		`{"jsonrpc": "2.0", "method": "$/cancelRequest", "params": {"id": 1}}`,
+	)
+	if !assert.Len(t, responses, 2) {
+		return
+	}
+	// the duplicate is rejected without replacing the running call, which is still cancelled by the ID.
+	assert.Equal(t, &rpcError{Code: rpcInvalidRequest, Message: "request id 1 is already in flight"}, responses[0].Error)
+	assert.JSONEq(t, `{"exitCode": 1, "stdout": "", "stderr": "ERROR : context canceled\n"}`, string(responses[1].Result))
+}
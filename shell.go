@@ -0,0 +1,224 @@
+package mycmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+var _ SubCommand = (*Shell)(nil)
+
+// Shell is a command which reads command lines repeatedly and runs them with its parent command.
+// Add it to a Root (or any ParentBase) to provide an interactive mode.
+// Command lines are read from InReader, which is set by SetInReader.
+type Shell struct {
+	*Base
+	history []string
+	// running is true while the shell reads the command lines.
+	running bool
+}
+
+func NewShell(name string, cfg BaseConfig) *Shell {
+	return &Shell{
+		Base: NewBase(name, cfg),
+	}
+}
+
+const shellHelp = `
+Shell commands:
+
+  exit [code]   exit the shell (quit is an alias)
+  history       print the command history
+  help          print this help
+`
+
+// History returns command lines read so far.
+func (c *Shell) History() []string {
+	return c.history
+}
+
+// Reset forgets the history of the previous execution as well as the flags.
+// The history is kept while the shell is running, because the parent is reset before each command line.
+func (c *Shell) Reset() {
+	c.Base.Reset()
+	if !c.running {
+		c.history = nil
+	}
+}
+
+func (c *Shell) prompt() string {
+	fullName := FullName(c)
+	return fmt.Sprintf("%s> ", strings.Join(fullName[:len(fullName)-1], " "))
+}
+
+func (c *Shell) newLineReader(parent ParentCommand) lineReader {
+	if f, ok := c.inReader.(*os.File); ok && isTerminalFile(f) {
+		return newLineEditor(f, c.outWriter,
+			func() []string { return c.history },
+			func(line string) []string { return c.complete(parent, line) },
+		)
+	}
+	return newPlainLineReader(c.inReader)
+}
+
+// Execute executes the main processing of the command.
+func (c *Shell) Execute() int {
+	return c.run(context.Background(), func(parent ParentCommand, args []string) int {
+		return RunCommand(parent, args)
+	})
+}
+
+// ExecuteContext is the same as Execute() but accept a context as an argument.
+// The context is passed to the commands run in the shell.
+func (c *Shell) ExecuteContext(ctx context.Context) int {
+	return c.run(ctx, func(parent ParentCommand, args []string) int {
+		return RunCommandContext(ctx, parent, args)
+	})
+}
+
+func (c *Shell) run(ctx context.Context, runCommand func(parent ParentCommand, args []string) int) int {
+	parent, ok := c.Parent().(ParentCommand)
+	if !ok {
+		c.PrintError(fmt.Sprintf("ERROR : %s must be a subcommand\n", c.Name()))
+		return 1
+	}
+
+	c.running = true
+	defer func() {
+		c.running = false
+	}()
+
+	reader := c.newLineReader(parent)
+	status := 0
+	for ctx.Err() == nil {
+		line, err := reader.ReadLine(c.prompt())
+		if err != nil {
+			if errors.Is(err, errInterrupted) {
+				continue
+			}
+			if errors.Is(err, io.EOF) {
+				return status
+			}
+			c.PrintError(fmt.Sprintf("ERROR : %s\n", err))
+			return 1
+		}
+
+		args, err := SplitArgs(line)
+		if err != nil {
+			c.PrintError(fmt.Sprintf("ERROR : %s\n", err))
+			status = 2
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+		c.history = append(c.history, line)
+
+		switch args[0] {
+		case "exit", "quit":
+			if len(args) > 1 {
+				code, err := strconv.Atoi(args[1])
+				if err != nil {
+					c.PrintError(fmt.Sprintf("ERROR : invalid exit code (%s)\n", args[1]))
+					status = 2
+					continue
+				}
+				return code
+			}
+			return status
+		case "history":
+			for i, h := range c.history {
+				c.Print(fmt.Sprintf("%5d  %s\n", i+1, h))
+			}
+			status = 0
+			continue
+		case "help":
+			if len(args) == 1 {
+				c.Print(parent.Usage())
+				c.Print(shellHelp)
+				status = 0
+				continue
+			}
+		case c.Name():
+			c.PrintError(fmt.Sprintf("ERROR : %s is already running\n", c.Name()))
+			status = 2
+			continue
+		}
+
+		// parse each line on the fresh state.
+		if v, ok := parent.(ResetSupported); ok {
+			v.Reset()
+		}
+		status = runCommand(parent, args)
+	}
+	return status
+}
+
+// complete returns the candidates for the last word of line.
+func (c *Shell) complete(parent ParentCommand, line string) []string {
+	words := strings.Fields(line)
+	partial := ""
+	if len(words) > 0 && !strings.HasSuffix(line, " ") {
+		partial = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+
+	var cur Command = parent
+	for _, w := range words {
+		if strings.HasPrefix(w, "-") {
+			continue
+		}
+		p, ok := cur.(ParentCommand)
+		if !ok {
+			break
+		}
+		if w == "help" {
+			// help takes the commands of the same level.
+			continue
+		}
+		next := findCommand(p, w)
+		if next == nil {
+			return nil
+		}
+		cur = next
+	}
+
+	candidates := []string{}
+	if strings.HasPrefix(partial, "-") {
+		availableFlags(cur).VisitAll(func(f *pflag.Flag) {
+			if !f.Hidden {
+				candidates = append(candidates, "--"+f.Name)
+			}
+		})
+	} else if p, ok := cur.(ParentCommand); ok {
+		candidates = append(candidates, "help")
+		for _, sub := range p.Commands() {
+			if v, ok := sub.(HiddenSupported); ok && v.Hidden() {
+				continue
+			}
+			if sub == Command(c) {
+				continue
+			}
+			candidates = append(candidates, sub.Name())
+		}
+		if len(words) == 0 {
+			candidates = append(candidates, "exit", "history")
+		}
+	}
+
+	matched := []string{}
+	for _, cand := range candidates {
+		if strings.HasPrefix(cand, partial) {
+			matched = append(matched, cand)
+		}
+	}
+	sort.Strings(matched)
+	return matched
+}
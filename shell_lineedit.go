@@ -0,0 +1,285 @@
+package mycmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kmio11/mycmd/internal/term"
+)
+
+// errInterrupted is returned by lineReader when the input is cancelled by Ctrl-C.
+var errInterrupted = errors.New("interrupted")
+
+type lineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+func isTerminalFile(f *os.File) bool {
+	return term.IsTerminal(f.Fd())
+}
+
+// plainLineReader reads lines without any editing feature.
+// It is used when the input is not a terminal.
+type plainLineReader struct {
+	r *bufio.Reader
+}
+
+func newPlainLineReader(r io.Reader) *plainLineReader {
+	return &plainLineReader{r: bufio.NewReader(r)}
+}
+
+func (r *plainLineReader) ReadLine(prompt string) (string, error) {
+	line, err := r.r.ReadString('\n')
+	if err != nil {
+		if errors.Is(err, io.EOF) && line != "" {
+			return line, nil
+		}
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// lineEditor reads lines from a terminal in raw mode,
+// supporting cursor movement, history and tab completion.
+type lineEditor struct {
+	in       *os.File
+	r        *bufio.Reader
+	out      io.Writer
+	history  func() []string
+	complete func(line string) []string
+
+	prompt string
+	buf    []rune
+	pos    int
+}
+
+func newLineEditor(in *os.File, out io.Writer, history func() []string, complete func(line string) []string) *lineEditor {
+	return &lineEditor{
+		in:       in,
+		r:        bufio.NewReader(in),
+		out:      out,
+		history:  history,
+		complete: complete,
+	}
+}
+
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlH     = 8
+	keyTab       = 9
+	keyLF        = 10
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyCR        = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyEscape    = 27
+	keyBackspace = 127
+)
+
+func (e *lineEditor) ReadLine(prompt string) (string, error) {
+	restore, err := term.MakeRaw(e.in.Fd())
+	if err != nil {
+		fmt.Fprint(e.out, prompt)
+		return newPlainLineReader(e.r).ReadLine(prompt)
+	}
+	defer restore()
+	return e.edit(prompt)
+}
+
+// edit reads the keys until the line is entered, assuming the terminal is in raw mode.
+func (e *lineEditor) edit(prompt string) (string, error) {
+	e.prompt = prompt
+	e.buf = []rune{}
+	e.pos = 0
+
+	history := e.history()
+	histIdx := len(history)
+	editing := ""
+
+	e.refresh()
+	for {
+		r, _, err := e.r.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case keyCR, keyLF:
+			fmt.Fprint(e.out, "\n")
+			return string(e.buf), nil
+		case keyCtrlC:
+			fmt.Fprint(e.out, "^C\n")
+			return "", errInterrupted
+		case keyCtrlD:
+			if len(e.buf) == 0 {
+				fmt.Fprint(e.out, "\n")
+				return "", io.EOF
+			}
+			e.delete()
+		case keyBackspace, keyCtrlH:
+			if e.pos > 0 {
+				e.pos--
+				e.delete()
+			}
+		case keyCtrlA:
+			e.pos = 0
+		case keyCtrlE:
+			e.pos = len(e.buf)
+		case keyCtrlB:
+			e.left()
+		case keyCtrlF:
+			e.right()
+		case keyCtrlK:
+			e.buf = e.buf[:e.pos]
+		case keyCtrlU:
+			e.buf = e.buf[e.pos:]
+			e.pos = 0
+		case keyCtrlL:
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+		case keyCtrlP:
+			histIdx, editing = e.moveHistory(history, histIdx, histIdx-1, editing)
+		case keyCtrlN:
+			histIdx, editing = e.moveHistory(history, histIdx, histIdx+1, editing)
+		case keyTab:
+			e.completeWord()
+		case keyEscape:
+			seq := e.readEscapeSequence()
+			switch seq {
+			case "[A", "OA":
+				histIdx, editing = e.moveHistory(history, histIdx, histIdx-1, editing)
+			case "[B", "OB":
+				histIdx, editing = e.moveHistory(history, histIdx, histIdx+1, editing)
+			case "[C", "OC":
+				e.right()
+			case "[D", "OD":
+				e.left()
+			case "[H", "OH", "[1~":
+				e.pos = 0
+			case "[F", "OF", "[4~":
+				e.pos = len(e.buf)
+			case "[3~":
+				e.delete()
+			}
+		default:
+			if r >= ' ' {
+				e.insert(r)
+			}
+		}
+		e.refresh()
+	}
+}
+
+func (e *lineEditor) readEscapeSequence() string {
+	var seq []rune
+	for {
+		r, _, err := e.r.ReadRune()
+		if err != nil {
+			return string(seq)
+		}
+		seq = append(seq, r)
+		// the sequence ends with a letter or '~' (except for the first '[' or 'O').
+		if len(seq) > 1 && (r == '~' || (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z')) {
+			return string(seq)
+		}
+		if len(seq) == 1 && r != '[' && r != 'O' {
+			return string(seq)
+		}
+	}
+}
+
+func (e *lineEditor) moveHistory(history []string, cur, next int, editing string) (int, string) {
+	if next < 0 || next > len(history) {
+		return cur, editing
+	}
+	if cur == len(history) {
+		editing = string(e.buf)
+	}
+	if next == len(history) {
+		e.buf = []rune(editing)
+	} else {
+		e.buf = []rune(history[next])
+	}
+	e.pos = len(e.buf)
+	return next, editing
+}
+
+func (e *lineEditor) insert(r rune) {
+	e.buf = append(e.buf, 0)
+	copy(e.buf[e.pos+1:], e.buf[e.pos:])
+	e.buf[e.pos] = r
+	e.pos++
+}
+
+func (e *lineEditor) delete() {
+	if e.pos < len(e.buf) {
+		e.buf = append(e.buf[:e.pos], e.buf[e.pos+1:]...)
+	}
+}
+
+func (e *lineEditor) left() {
+	if e.pos > 0 {
+		e.pos--
+	}
+}
+
+func (e *lineEditor) right() {
+	if e.pos < len(e.buf) {
+		e.pos++
+	}
+}
+
+// completeWord completes the word under the cursor.
+// When there are several candidates, the common prefix is completed and the candidates are listed.
+func (e *lineEditor) completeWord() {
+	if e.complete == nil {
+		return
+	}
+	head := string(e.buf[:e.pos])
+	candidates := e.complete(head)
+	if len(candidates) == 0 {
+		return
+	}
+
+	partial := head[strings.LastIndexAny(head, " \t")+1:]
+	completion := commonPrefix(candidates)
+	if len(candidates) == 1 {
+		completion += " "
+	}
+	if len(completion) > len(partial) {
+		for _, r := range completion[len(partial):] {
+			e.insert(r)
+		}
+		return
+	}
+
+	fmt.Fprintf(e.out, "\n%s\n", strings.Join(candidates, "  "))
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, w := range words[1:] {
+		for !strings.HasPrefix(w, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// refresh redraws the prompt and the line, then moves the cursor to its position.
+func (e *lineEditor) refresh() {
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", e.prompt, string(e.buf))
+	if n := len(e.buf) - e.pos; n > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", n)
+	}
+}
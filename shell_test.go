@@ -0,0 +1,248 @@
+package mycmd
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCompletionTree() (*Root, *Shell) {
+	edit := NewBase("edit", BaseConfig{Aliases: []string{"e"}})
+	edit.FS().Bool("json", false, "prints in JSON")
+	edit.FS().Bool("secret", false, "hidden flag")
+	_ = edit.FS().MarkHidden("secret")
+
+	build := NewBase("build", BaseConfig{Aliases: []string{"b"}})
+	build.FS().String("out", "", "output file")
+	build.FS().Bool("race", false, "enable data race detection")
+
+	shell := NewShell("shell", BaseConfig{})
+	root := NewRoot("app").AddCommands(
+		NewParentBase("mod", BaseConfig{}).AddCommands(edit),
+		build,
+		NewBase("internal", BaseConfig{Hidden: true}),
+		shell,
+	)
+	root.PersistentFS().Bool("verbose", false, "verbose output")
+	return root, shell
+}
+
+func TestShell_complete(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{
+			name: "top level",
+			line: "",
+			want: []string{"build", "exit", "help", "history", "mod"},
+		},
+		{
+			name: "command prefix",
+			line: "b",
+			want: []string{"build"},
+		},
+		{
+			name: "subcommands",
+			line: "mod ",
+			want: []string{"edit", "help"},
+		},
+		{
+			name: "subcommand prefix",
+			line: "mod e",
+			want: []string{"edit"},
+		},
+		{
+			name: "help takes the commands of the same level",
+			line: "help m",
+			want: []string{"mod"},
+		},
+		{
+			name: "hidden command is not completed",
+			line: "int",
+			want: []string{},
+		},
+		{
+			name: "flags except hidden ones",
+			line: "mod edit --",
+			want: []string{"--json", "--verbose"},
+		},
+		{
+			name: "persistent flags of the root",
+			line: "--v",
+			want: []string{"--verbose"},
+		},
+		{
+			name: "flags of the command resolved by alias",
+			line: "b --r",
+			want: []string{"--race"},
+		},
+		{
+			name: "flags are skipped in the path",
+			line: "build --race --o",
+			want: []string{"--out"},
+		},
+		{
+			name: "no candidates after leaf command",
+			line: "mod edit x",
+			want: []string{},
+		},
+		{
+			name: "unknown command",
+			line: "unknown ",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, shell := newCompletionTree()
+			assert.Equal(t, tt.want, shell.complete(root, tt.line))
+		})
+	}
+}
+
+func TestLineEditor_edit(t *testing.T) {
+	complete := func(line string) []string {
+		words := strings.Fields(line)
+		partial := ""
+		if len(words) > 0 && !strings.HasSuffix(line, " ") {
+			partial = words[len(words)-1]
+		}
+		matched := []string{}
+		for _, cand := range []string{"build", "mod", "move"} {
+			if strings.HasPrefix(cand, partial) {
+				matched = append(matched, cand)
+			}
+		}
+		return matched
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+		wantOut string
+	}{
+		{
+			name:  "enter",
+			input: "abc\r",
+			want:  "abc",
+		},
+		{
+			name:  "line feed",
+			input: "abc\n",
+			want:  "abc",
+		},
+		{
+			name:  "backspace",
+			input: "abc\x7f\x7fd\r",
+			want:  "ad",
+		},
+		{
+			name:  "insert after moving left",
+			input: "ac\x1b[Db\r",
+			want:  "abc",
+		},
+		{
+			name:  "move right",
+			input: "ac\x02\x02\x1b[Cb\r",
+			want:  "abc",
+		},
+		{
+			name:  "home and end",
+			input: "world\x01hello \x05!\r",
+			want:  "hello world!",
+		},
+		{
+			name:  "kill to the end",
+			input: "abcdef\x1b[D\x1b[D\x0b\r",
+			want:  "abcd",
+		},
+		{
+			name:  "kill to the beginning",
+			input: "abcdef\x1b[D\x1b[D\x15\r",
+			want:  "ef",
+		},
+		{
+			name:  "delete key",
+			input: "ab\x1b[D\x1b[3~\r",
+			want:  "a",
+		},
+		{
+			name:  "ctrl-d deletes the character under the cursor",
+			input: "ab\x01\x04\r",
+			want:  "b",
+		},
+		{
+			name:  "previous history",
+			input: "\x1b[A\x1b[A\r",
+			want:  "first",
+		},
+		{
+			name:  "history stops at the oldest",
+			input: "\x10\x10\x10\r",
+			want:  "first",
+		},
+		{
+			name:  "next history restores the editing line",
+			input: "ed\x1b[A\x1b[B\r",
+			want:  "ed",
+		},
+		{
+			name:  "complete the only candidate",
+			input: "b\t\r",
+			want:  "build ",
+		},
+		{
+			name:    "complete the common prefix and list the candidates",
+			input:   "m\t\t\r",
+			want:    "mo",
+			wantOut: "\nmod  move\n",
+		},
+		{
+			name:    "ctrl-c",
+			input:   "abc\x03",
+			wantErr: errInterrupted,
+		},
+		{
+			name:    "ctrl-d on empty line",
+			input:   "\x04",
+			wantErr: io.EOF,
+		},
+		{
+			name:    "input is closed",
+			input:   "abc",
+			wantErr: io.EOF,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			e := &lineEditor{
+				r:        bufio.NewReader(strings.NewReader(tt.input)),
+				out:      &out,
+				history:  func() []string { return []string{"first", "second"} },
+				complete: complete,
+			}
+
+			actual, err := e.edit("> ")
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, actual)
+			assert.Contains(t, out.String(), tt.wantOut)
+		})
+	}
+}
+
+func TestLineEditor_refresh(t *testing.T) {
+	var out bytes.Buffer
+	e := &lineEditor{out: &out, prompt: "> ", buf: []rune("abcd"), pos: 1}
+	e.refresh()
+	assert.Equal(t, "\r> abcd\x1b[K\x1b[3D", out.String())
+}
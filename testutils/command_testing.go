@@ -2,9 +2,13 @@ package testutils
 
 import (
 	"bytes"
+	"context"
 	"flag"
 	"fmt"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/kmio11/mycmd"
 	"github.com/stretchr/testify/assert"
@@ -26,7 +30,8 @@ type (
 	SetupFunc[T any] func(t *testing.T, tt T)
 )
 
-func setup[T any](t *testing.T, tt T, newCmd Factory, setupFunc SetupFunc[T]) (cmd mycmd.Command, outWriter, errWriter *bytes.Buffer) {
+// setup makes the command whose standard input is stdin and whose outputs are written to the returned buffers.
+func setup[T any](t *testing.T, tt T, stdin string, newCmd Factory, setupFunc SetupFunc[T]) (cmd mycmd.Command, outWriter, errWriter *bytes.Buffer) {
 	if setupFunc != nil {
 		setupFunc(t, tt)
 	}
@@ -36,11 +41,15 @@ func setup[T any](t *testing.T, tt T, newCmd Factory, setupFunc SetupFunc[T]) (c
 	outWriter, errWriter = new(bytes.Buffer), new(bytes.Buffer)
 	cmd.SetOutWriter(outWriter)
 	cmd.SetErrWriter(errWriter)
+	if v, ok := cmd.(mycmd.InReaderSupported); ok {
+		v.SetInReader(strings.NewReader(stdin))
+	}
 
 	return
 }
 
 func AssertStdOutAndStdErr(t *testing.T, testdata *TestData, ttName string, outWriter, errWriter *bytes.Buffer) {
+	t.Helper()
 	// assert stdout
 	testdata.CompareWithGolden(t, *update,
 		testdata.FileName(t,
@@ -61,6 +70,7 @@ func AssertStdOutAndStdErr(t *testing.T, testdata *TestData, ttName string, outW
 type TestCaseRootParseAndExecute struct {
 	Name  string
 	Args  []string
+	Stdin string
 	Want  int
 	Setup SetupFunc[TestCaseRootParseAndExecute]
 }
@@ -74,7 +84,7 @@ func RunTestRoot_ParseAndExecute(
 ) {
 	for _, tt := range tests {
 		t.Run(tt.Name, func(t *testing.T) {
-			cmd, outWriter, errWriter := setup[TestCaseRootParseAndExecute](t, tt, newCmd, tt.Setup)
+			cmd, outWriter, errWriter := setup[TestCaseRootParseAndExecute](t, tt, tt.Stdin, newCmd, tt.Setup)
 
 			root, ok := cmd.(*mycmd.Root)
 			if !ok {
@@ -110,7 +120,7 @@ func RunTestCommand_Usage(
 ) {
 	for _, tt := range tests {
 		t.Run(tt.Name, func(t *testing.T) {
-			cmd, outWriter, errWriter := setup[TestCaseUsage](t, tt, newCmd, tt.Setup)
+			cmd, outWriter, errWriter := setup[TestCaseUsage](t, tt, "", newCmd, tt.Setup)
 
 			actual := cmd.Usage()
 
@@ -135,6 +145,7 @@ func RunTestCommand_Usage(
 type TestCaseParse struct {
 	Name      string
 	Args      []string
+	Stdin     string
 	WantError bool
 	Setup     SetupFunc[TestCaseParse]
 }
@@ -148,7 +159,7 @@ func RunTestCommand_Parse(
 ) {
 	for _, tt := range tests {
 		t.Run(tt.Name, func(t *testing.T) {
-			cmd, outWriter, errWriter := setup[TestCaseParse](t, tt, newCmd, tt.Setup)
+			cmd, outWriter, errWriter := setup[TestCaseParse](t, tt, tt.Stdin, newCmd, tt.Setup)
 
 			actual := cmd.Parse(tt.Args)
 
@@ -178,6 +189,7 @@ func RunTestCommand_Parse(
 type TestCaseExecute struct {
 	Name  string
 	Args  []string
+	Stdin string
 	Want  int
 	Setup SetupFunc[TestCaseExecute]
 }
@@ -191,7 +203,7 @@ func RunTestCommand_Execute(
 ) {
 	for _, tt := range tests {
 		t.Run(tt.Name, func(t *testing.T) {
-			cmd, outWriter, errWriter := setup[TestCaseExecute](t, tt, newCmd, tt.Setup)
+			cmd, outWriter, errWriter := setup[TestCaseExecute](t, tt, tt.Stdin, newCmd, tt.Setup)
 
 			err := cmd.Parse(tt.Args)
 			if err != nil {
@@ -212,3 +224,181 @@ func RunTestCommand_Execute(
 		})
 	}
 }
+
+type TestCaseParentParseAndExecute struct {
+	Name  string
+	Args  []string
+	Stdin string
+	// Env is set by testing.T.Setenv before the command is made.
+	Env map[string]string
+	// Dir is the working directory while the command is parsed and executed.
+	Dir string
+	// Timeout sets the deadline of the context. A negative value makes the context already expired.
+	Timeout time.Duration
+	// Cancelled cancels the context before the command is parsed and executed.
+	Cancelled bool
+	// Parallel runs the case in parallel with the other parallel cases. It cannot be used with Env and Dir.
+	Parallel bool
+	Want     int
+	Setup    SetupFunc[TestCaseParentParseAndExecute]
+}
+
+// RunTestParent_ParseAndExecuteContext is like RunTestRoot_ParseAndExecute,
+// but accepts any ParentCommand and runs it with mycmd.RunCommandContext and the context of each case.
+// The case without Timeout and Cancelled is run with mycmd.RunCommand, so the commands implementing only Execute can be tested.
+func RunTestParent_ParseAndExecuteContext(
+	t *testing.T,
+	tests []TestCaseParentParseAndExecute,
+	testdata *TestData,
+	newCmd Factory,
+	customAssertion CustomAssertion[TestCaseParentParseAndExecute],
+) {
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.Name, func(t *testing.T) {
+			prepareCase(t, tt.Parallel, tt.Env, tt.Dir)
+			cmd, outWriter, errWriter := setup[TestCaseParentParseAndExecute](t, tt, tt.Stdin, newCmd, tt.Setup)
+
+			parent, ok := cmd.(mycmd.ParentCommand)
+			if !ok {
+				t.Fatal("cmd is not ParentCommand")
+			}
+
+			ctx := caseContext(t, tt.Timeout, tt.Cancelled)
+			var actual int
+			inDir(t, tt.Dir, func() {
+				if hasContext(tt.Timeout, tt.Cancelled) {
+					actual = mycmd.RunCommandContext(ctx, parent, tt.Args)
+				} else {
+					actual = mycmd.RunCommand(parent, tt.Args)
+				}
+			})
+
+			// assert status code
+			assert.Equal(t, tt.Want, actual)
+
+			// assert stdout and stderr
+			AssertStdOutAndStdErr(t, testdata, tt.Name, outWriter, errWriter)
+
+			if customAssertion != nil {
+				customAssertion(t, tt, *update, cmd, []any{actual})
+			}
+		})
+	}
+}
+
+type TestCaseExecuteContext struct {
+	Name  string
+	Args  []string
+	Stdin string
+	// Env is set by testing.T.Setenv before the command is made.
+	Env map[string]string
+	// Dir is the working directory while the command is parsed and executed.
+	Dir string
+	// Timeout sets the deadline of the context. A negative value makes the context already expired.
+	Timeout time.Duration
+	// Cancelled cancels the context before the command is executed.
+	Cancelled bool
+	// Parallel runs the case in parallel with the other parallel cases. It cannot be used with Env and Dir.
+	Parallel bool
+	Want     int
+	Setup    SetupFunc[TestCaseExecuteContext]
+}
+
+// RunTestCommand_ExecuteContext is like RunTestCommand_Execute, but executes the command with ExecuteContext and the context of each case.
+// The case without Timeout and Cancelled is executed with Execute, so the commands implementing only Execute can be tested.
+func RunTestCommand_ExecuteContext(
+	t *testing.T,
+	tests []TestCaseExecuteContext,
+	testdata *TestData,
+	newCmd Factory,
+	customAssertion CustomAssertion[TestCaseExecuteContext],
+) {
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.Name, func(t *testing.T) {
+			prepareCase(t, tt.Parallel, tt.Env, tt.Dir)
+			cmd, outWriter, errWriter := setup[TestCaseExecuteContext](t, tt, tt.Stdin, newCmd, tt.Setup)
+
+			ctx := caseContext(t, tt.Timeout, tt.Cancelled)
+			var actual int
+			inDir(t, tt.Dir, func() {
+				err := cmd.Parse(tt.Args)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if hasContext(tt.Timeout, tt.Cancelled) {
+					actual = cmd.ExecuteContext(ctx)
+				} else {
+					actual = cmd.Execute()
+				}
+			})
+
+			// assert status code
+			assert.Equal(t, tt.Want, actual)
+
+			// assert stdout and stderr
+			AssertStdOutAndStdErr(t, testdata, tt.Name, outWriter, errWriter)
+
+			if customAssertion != nil {
+				customAssertion(t, tt, *update, cmd, []any{actual})
+			}
+		})
+	}
+}
+
+// prepareCase marks the case parallel or sets the environment variables.
+// The environment variables and the working directory are shared by the process, so they cannot be changed by the parallel cases.
+func prepareCase(t *testing.T, parallel bool, env map[string]string, dir string) {
+	t.Helper()
+	if parallel {
+		if len(env) > 0 || dir != "" {
+			t.Fatal("Env and Dir cannot be used with Parallel")
+		}
+		t.Parallel()
+		return
+	}
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+}
+
+// hasContext reports whether the case sets the context, i.e. it needs ExecuteContext to be executed.
+func hasContext(timeout time.Duration, cancelled bool) bool {
+	return timeout != 0 || cancelled
+}
+
+func caseContext(t *testing.T, timeout time.Duration, cancelled bool) context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	if timeout != 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		t.Cleanup(cancel)
+	}
+	if cancelled {
+		cancel()
+	}
+	return ctx
+}
+
+// inDir calls f in the working directory dir, or the current directory if dir is empty.
+func inDir(t *testing.T, dir string, f func()) {
+	t.Helper()
+	if dir == "" {
+		f()
+		return
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	f()
+}
@@ -0,0 +1,236 @@
+package testutils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kmio11/mycmd"
+	"github.com/kmio11/mycmd/wflag"
+	"github.com/spf13/pflag"
+)
+
+// commandSurface is what the users of a command depend on.
+type commandSurface struct {
+	Path       string        `json:"path"`
+	Aliases    []string      `json:"aliases,omitempty"`
+	Hidden     bool          `json:"hidden,omitempty"`
+	Deprecated string        `json:"deprecated,omitempty"`
+	Flags      []flagSurface `json:"flags,omitempty"`
+	Args       []argSurface  `json:"args,omitempty"`
+}
+
+type flagSurface struct {
+	Name       string `json:"name"`
+	Shorthand  string `json:"shorthand,omitempty"`
+	Type       string `json:"type"`
+	Required   bool   `json:"required,omitempty"`
+	Hidden     bool   `json:"hidden,omitempty"`
+	Deprecated string `json:"deprecated,omitempty"`
+}
+
+type argSurface struct {
+	Index    int    `json:"index"`
+	Name     string `json:"name"`
+	Required bool   `json:"required,omitempty"`
+}
+
+// CheckCompatibility compares the command surface of the tree made by newCmd with the snapshot golden/surface.json,
+// which is the surface of the last release. The surface consists of the command paths, the aliases,
+// the flags available to each command (including the inherited ones) with their types and shorthands,
+// the positional arguments, and the hidden and deprecated status.
+//
+// Additions are compatible, but the test fails if anything is removed or changed incompatibly,
+// e.g. a flag is renamed, its type or shorthand is changed, or it becomes required.
+// Commands and flags can be removed only if they are deprecated in the snapshot.
+// Hiding or deprecating a command or a flag is compatible.
+//
+// The validation rules set by SetValidationRules are not a part of the surface, so tightening them is not detected,
+// e.g. adding fv.Flag("out").Required() or reducing the number of fv.NumberOfArgs.
+// Use MarkRequired of the flags and the required positional arguments for the constraints which should be checked.
+//
+// With the -update flag, the snapshot is rewritten with the current surface if it is compatible.
+// If the breaking change is intended, e.g. for a major release, remove the snapshot and run with -update.
+func CheckCompatibility(t *testing.T, testdata *TestData, newCmd Factory) {
+	t.Helper()
+	actual := surfaceOf(newCmd())
+	actualData, err := json.MarshalIndent(actual, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	actualData = append(actualData, '\n')
+
+	fileName := testdata.FileName(t, "golden", "surface.json")
+	testdata.markCompared(fileName)
+	expectedData, err := os.ReadFile(fileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		if *update {
+			testdata.WriteFile(t, fileName, actualData)
+			return
+		}
+		t.Fatalf("%s does not exist (run with -update to record the command surface)", fileName)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []commandSurface{}
+	if err := json.Unmarshal(expectedData, &expected); err != nil {
+		t.Fatalf("%s: %s", fileName, err)
+	}
+	if problems := incompatibilities(expected, actual); len(problems) > 0 {
+		t.Errorf("the command surface is incompatible with %s:\n\t%s\n"+
+			"(remove it and run with -update if the breaking change is intended)",
+			fileName, strings.Join(problems, "\n\t"),
+		)
+		return
+	}
+
+	if string(expectedData) == string(actualData) {
+		return
+	}
+	if *update {
+		testdata.WriteFile(t, fileName, actualData)
+		return
+	}
+	t.Errorf("%s differs from the compatible actual surface (run with -update to update it):\n%s",
+		fileName, unifiedDiff(fileName, "actual", string(expectedData), string(actualData)),
+	)
+}
+
+// surfaceOf returns the surfaces of c and its descendants in the depth-first order.
+func surfaceOf(c mycmd.Command) []commandSurface {
+	surfaces := []commandSurface{}
+	var walk func(c mycmd.Command, inherited []*wflag.FlagSet)
+	walk = func(c mycmd.Command, inherited []*wflag.FlagSet) {
+		s := commandSurface{
+			Path: strings.Join(mycmd.FullName(c), " "),
+		}
+		if v, ok := c.(mycmd.AliasesSupported); ok {
+			s.Aliases = v.Aliases()
+		}
+		if v, ok := c.(mycmd.HiddenSupported); ok {
+			s.Hidden = v.Hidden()
+		}
+		if v, ok := c.(mycmd.DeprecatedSupported); ok {
+			s.Deprecated = v.Deprecated()
+		}
+
+		// the own flags take precedence over the inherited ones like the parsing does.
+		sets := []*wflag.FlagSet{}
+		if v, ok := c.(mycmd.FlagSetSupported); ok {
+			sets = append(sets, v.FS())
+			for _, a := range v.FS().Arguments() {
+				s.Args = append(s.Args, argSurface{Index: a.Index, Name: a.Name, Required: a.Required})
+			}
+		}
+		if v, ok := c.(mycmd.PersistentFlagSetSupported); ok {
+			inherited = append(slices.Clip(inherited), v.PersistentFS())
+		}
+		for i := len(inherited) - 1; i >= 0; i-- {
+			sets = append(sets, inherited[i])
+		}
+		for _, set := range sets {
+			set.VisitAll(func(f *pflag.Flag) {
+				if slices.ContainsFunc(s.Flags, func(sf flagSurface) bool { return sf.Name == f.Name }) {
+					return
+				}
+				s.Flags = append(s.Flags, flagSurface{
+					Name:       f.Name,
+					Shorthand:  f.Shorthand,
+					Type:       f.Value.Type(),
+					Required:   set.IsRequired(f.Name),
+					Hidden:     f.Hidden,
+					Deprecated: f.Deprecated,
+				})
+			})
+		}
+		surfaces = append(surfaces, s)
+
+		if p, ok := c.(mycmd.ParentCommand); ok {
+			for _, sub := range p.Commands() {
+				walk(sub, inherited)
+			}
+		}
+	}
+	walk(c, nil)
+	return surfaces
+}
+
+// incompatibilities returns the descriptions of the changes from before to after which break the users of before.
+func incompatibilities(before, after []commandSurface) []string {
+	problems := []string{}
+	for _, o := range before {
+		i := slices.IndexFunc(after, func(n commandSurface) bool { return n.Path == o.Path })
+		if i < 0 {
+			if o.Deprecated == "" {
+				problems = append(problems, fmt.Sprintf("command %q is removed without deprecation", o.Path))
+			}
+			continue
+		}
+		n := after[i]
+
+		for _, alias := range o.Aliases {
+			if !slices.Contains(n.Aliases, alias) {
+				problems = append(problems, fmt.Sprintf("alias %q of command %q is removed", alias, o.Path))
+			}
+		}
+		problems = append(problems, flagIncompatibilities(o, n)...)
+		problems = append(problems, argIncompatibilities(o, n)...)
+	}
+	return problems
+}
+
+func flagIncompatibilities(before, after commandSurface) []string {
+	problems := []string{}
+	for _, o := range before.Flags {
+		i := slices.IndexFunc(after.Flags, func(n flagSurface) bool { return n.Name == o.Name })
+		if i < 0 {
+			if o.Deprecated == "" {
+				problems = append(problems, fmt.Sprintf("flag --%s of %q is removed without deprecation", o.Name, before.Path))
+			}
+			continue
+		}
+		n := after.Flags[i]
+		if o.Type != n.Type {
+			problems = append(problems, fmt.Sprintf("type of flag --%s of %q is changed from %s to %s", o.Name, before.Path, o.Type, n.Type))
+		}
+		if o.Shorthand != "" && o.Shorthand != n.Shorthand {
+			problems = append(problems, fmt.Sprintf("shorthand -%s of flag --%s of %q is changed to %q", o.Shorthand, o.Name, before.Path, n.Shorthand))
+		}
+		if !o.Required && n.Required {
+			problems = append(problems, fmt.Sprintf("flag --%s of %q becomes required", o.Name, before.Path))
+		}
+	}
+	for _, n := range after.Flags {
+		if n.Required && !slices.ContainsFunc(before.Flags, func(o flagSurface) bool { return o.Name == n.Name }) {
+			problems = append(problems, fmt.Sprintf("required flag --%s is added to %q", n.Name, before.Path))
+		}
+	}
+	return problems
+}
+
+func argIncompatibilities(before, after commandSurface) []string {
+	problems := []string{}
+	for _, o := range before.Args {
+		i := slices.IndexFunc(after.Args, func(n argSurface) bool { return n.Index == o.Index })
+		if i < 0 {
+			problems = append(problems, fmt.Sprintf("argument %d (%s) of %q is removed", o.Index, o.Name, before.Path))
+			continue
+		}
+		if !o.Required && after.Args[i].Required {
+			problems = append(problems, fmt.Sprintf("argument %d (%s) of %q becomes required", o.Index, o.Name, before.Path))
+		}
+	}
+	for _, n := range after.Args {
+		if n.Required && !slices.ContainsFunc(before.Args, func(o argSurface) bool { return o.Index == n.Index }) {
+			problems = append(problems, fmt.Sprintf("required argument %d (%s) is added to %q", n.Index, n.Name, before.Path))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,146 @@
+package testutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncompatibilities(t *testing.T) {
+	build := func(flags []flagSurface, args []argSurface) []commandSurface {
+		return []commandSurface{
+			{Path: "app"},
+			{Path: "app build", Aliases: []string{"b"}, Flags: flags, Args: args},
+		}
+	}
+	out := flagSurface{Name: "out", Shorthand: "o", Type: "string"}
+	race := flagSurface{Name: "race", Type: "bool"}
+	pkg := argSurface{Index: 0, Name: "package"}
+
+	tests := []struct {
+		name   string
+		before []commandSurface
+		after  []commandSurface
+		want   []string
+	}{
+		{
+			name:   "unchanged",
+			before: build([]flagSurface{out, race}, []argSurface{pkg}),
+			after:  build([]flagSurface{out, race}, []argSurface{pkg}),
+			want:   []string{},
+		},
+		{
+			name:   "additions are compatible",
+			before: build([]flagSurface{out}, nil),
+			after: append(
+				build([]flagSurface{out, race}, []argSurface{pkg}),
+				commandSurface{Path: "app clean"},
+			),
+			want: []string{},
+		},
+		{
+			name:   "removed flag",
+			before: build([]flagSurface{out, race}, nil),
+			after:  build([]flagSurface{out}, nil),
+			want:   []string{`flag --race of "app build" is removed without deprecation`},
+		},
+		{
+			name:   "removed deprecated flag",
+			before: build([]flagSurface{out, {Name: "race", Type: "bool", Deprecated: "it is always enabled"}}, nil),
+			after:  build([]flagSurface{out}, nil),
+			want:   []string{},
+		},
+		{
+			name:   "changed shorthand",
+			before: build([]flagSurface{out}, nil),
+			after:  build([]flagSurface{{Name: "out", Shorthand: "O", Type: "string"}}, nil),
+			want:   []string{`shorthand -o of flag --out of "app build" is changed to "O"`},
+		},
+		{
+			name:   "removed shorthand",
+			before: build([]flagSurface{out}, nil),
+			after:  build([]flagSurface{{Name: "out", Type: "string"}}, nil),
+			want:   []string{`shorthand -o of flag --out of "app build" is changed to ""`},
+		},
+		{
+			name:   "changed type",
+			before: build([]flagSurface{race}, nil),
+			after:  build([]flagSurface{{Name: "race", Type: "string"}}, nil),
+			want:   []string{`type of flag --race of "app build" is changed from bool to string`},
+		},
+		{
+			name:   "flag becomes required",
+			before: build([]flagSurface{out}, nil),
+			after:  build([]flagSurface{{Name: "out", Shorthand: "o", Type: "string", Required: true}}, nil),
+			want:   []string{`flag --out of "app build" becomes required`},
+		},
+		{
+			name:   "required flag is added",
+			before: build(nil, nil),
+			after:  build([]flagSurface{{Name: "out", Type: "string", Required: true}}, nil),
+			want:   []string{`required flag --out is added to "app build"`},
+		},
+		{
+			name:   "removed argument",
+			before: build(nil, []argSurface{pkg}),
+			after:  build(nil, nil),
+			want:   []string{`argument 0 (package) of "app build" is removed`},
+		},
+		{
+			name:   "argument becomes required",
+			before: build(nil, []argSurface{pkg}),
+			after:  build(nil, []argSurface{{Index: 0, Name: "package", Required: true}}),
+			want:   []string{`argument 0 (package) of "app build" becomes required`},
+		},
+		{
+			name:   "removed alias",
+			before: build(nil, nil),
+			after:  []commandSurface{{Path: "app"}, {Path: "app build"}},
+			want:   []string{`alias "b" of command "app build" is removed`},
+		},
+		{
+			name:   "removed command",
+			before: build(nil, nil),
+			after:  []commandSurface{{Path: "app"}},
+			want:   []string{`command "app build" is removed without deprecation`},
+		},
+		{
+			name: "removed deprecated command",
+			before: []commandSurface{
+				{Path: "app"},
+				{Path: "app build", Deprecated: "use app make instead"},
+			},
+			after: []commandSurface{{Path: "app"}},
+			want:  []string{},
+		},
+		{
+			name:   "hiding and deprecating are compatible",
+			before: build([]flagSurface{out}, nil),
+			after: []commandSurface{
+				{Path: "app"},
+				{
+					Path: "app build", Aliases: []string{"b"}, Hidden: true, Deprecated: "use app make instead",
+					Flags: []flagSurface{{Name: "out", Shorthand: "o", Type: "string", Hidden: true, Deprecated: "use --output"}},
+				},
+			},
+			want: []string{},
+		},
+		{
+			name:   "multiple problems",
+			before: build([]flagSurface{out, race}, []argSurface{pkg}),
+			after:  []commandSurface{{Path: "app"}, {Path: "app build", Flags: []flagSurface{{Name: "out", Type: "int"}}}},
+			want: []string{
+				`alias "b" of command "app build" is removed`,
+				`type of flag --out of "app build" is changed from string to int`,
+				`shorthand -o of flag --out of "app build" is changed to ""`,
+				`flag --race of "app build" is removed without deprecation`,
+				`argument 0 (package) of "app build" is removed`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, incompatibilities(tt.before, tt.after))
+		})
+	}
+}
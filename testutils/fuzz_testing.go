@@ -0,0 +1,221 @@
+package testutils
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kmio11/mycmd"
+	"github.com/spf13/pflag"
+)
+
+// FuzzOptions configures FuzzCommand.
+type FuzzOptions struct {
+	// Seeds are the argument vectors added to the seed corpus in addition to the ones generated from the command tree,
+	// e.g. ArgsOf(tests) of the golden test cases.
+	Seeds [][]string
+	// ExitCodes are the documented exit codes. {0, 1, 2} is used if it is empty.
+	ExitCodes []int
+	// Timeout is the time limit of each execution. 10 seconds is used if it is zero.
+	Timeout time.Duration
+	// Skip reports whether args should not be executed, e.g. the commands which have side effects outside of the work directory.
+	Skip func(args []string) bool
+}
+
+// ArgsOf returns Args of the test cases, which can be used as FuzzOptions.Seeds.
+func ArgsOf(tests []TestCaseRootParseAndExecute) [][]string {
+	args := [][]string{}
+	for _, tt := range tests {
+		args = append(args, tt.Args)
+	}
+	return args
+}
+
+// FuzzCommand fuzzes the argument vector of the command made by newCmd with Go native fuzzing.
+// The seed corpus is generated from the commands and the flags of the tree, and opts.Seeds.
+//
+// It asserts that the command never panics, finishes within opts.Timeout, always returns one of the documented exit codes,
+// and never writes to os.Stdout or os.Stderr directly instead of OutWriter and ErrWriter.
+// The command is executed in a temporary work directory with an empty standard input.
+func FuzzCommand(f *testing.F, newCmd Factory, opts FuzzOptions) {
+	exitCodes := opts.ExitCodes
+	if len(exitCodes) == 0 {
+		exitCodes = []int{0, 1, 2}
+	}
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+
+	for _, args := range append(seedArgs(newCmd()), opts.Seeds...) {
+		args, err := absResponseFiles(args)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(joinFuzzArgs(args))
+	}
+
+	workDir := f.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		f.Fatal(err)
+	}
+	if err := os.Chdir(workDir); err != nil {
+		f.Fatal(err)
+	}
+	f.Cleanup(func() {
+		os.Chdir(wd)
+	})
+
+	stdout, err := os.CreateTemp(f.TempDir(), "stdout")
+	if err != nil {
+		f.Fatal(err)
+	}
+	stderr, err := os.CreateTemp(f.TempDir(), "stderr")
+	if err != nil {
+		f.Fatal(err)
+	}
+	origStdout, origStderr := os.Stdout, os.Stderr
+	f.Cleanup(func() {
+		os.Stdout, os.Stderr = origStdout, origStderr
+		stdout.Close()
+		stderr.Close()
+	})
+
+	f.Fuzz(func(t *testing.T, joined string) {
+		args := splitFuzzArgs(joined)
+		if opts.Skip != nil && opts.Skip(args) {
+			t.Skip()
+		}
+
+		for _, f := range []*os.File{stdout, stderr} {
+			if err := f.Truncate(0); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// the command is made after the replacement so that the default writers are also the files.
+		os.Stdout, os.Stderr = stdout, stderr
+		defer func() {
+			os.Stdout, os.Stderr = origStdout, origStderr
+		}()
+
+		cmd := newCmd()
+		outWriter, errWriter := new(bytes.Buffer), new(bytes.Buffer)
+		cmd.SetOutWriter(outWriter)
+		cmd.SetErrWriter(errWriter)
+		if v, ok := cmd.(mycmd.InReaderSupported); ok {
+			v.SetInReader(strings.NewReader(""))
+		}
+
+		var (
+			code     int
+			panicked any
+			done     = make(chan struct{})
+		)
+		go func() {
+			defer close(done)
+			code, panicked = runRecovered(cmd, args)
+		}()
+		select {
+		case <-done:
+		case <-time.After(timeout):
+			t.Fatalf("not finished in %s with args %q", timeout, args)
+		}
+		if panicked != nil {
+			t.Fatalf("panic with args %q: %v", args, panicked)
+		}
+		if !slices.Contains(exitCodes, code) {
+			t.Errorf("undocumented exit code %d with args %q\nstdout:\n%s\nstderr:\n%s", code, args, outWriter, errWriter)
+		}
+		for name, f := range map[string]*os.File{"os.Stdout": stdout, "os.Stderr": stderr} {
+			data, err := os.ReadFile(f.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(data) > 0 {
+				t.Errorf("written to %s directly with args %q:\n%s", name, args, data)
+			}
+		}
+	})
+}
+
+func runRecovered(cmd mycmd.Command, args []string) (code int, panicked any) {
+	defer func() {
+		panicked = recover()
+	}()
+	return mycmd.RunCommand(cmd, args), nil
+}
+
+// the arguments are joined by NUL, which cannot be in the command line arguments.
+// absResponseFiles returns a copy of args whose relative response file paths, e.g. "@testdata/args.rsp",
+// are made absolute, so that the seeds still read the files after FuzzCommand changes the work directory.
+func absResponseFiles(args []string) ([]string, error) {
+	resolved := make([]string, len(args))
+	for i, arg := range args {
+		resolved[i] = arg
+		if !strings.HasPrefix(arg, "@") || strings.HasPrefix(arg, "@@") || arg == "@" {
+			continue
+		}
+		path, err := filepath.Abs(arg[1:])
+		if err != nil {
+			return nil, err
+		}
+		resolved[i] = "@" + path
+	}
+	return resolved, nil
+}
+
+func joinFuzzArgs(args []string) string {
+	return strings.Join(args, "\x00")
+}
+
+func splitFuzzArgs(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, "\x00")
+}
+
+// seedArgs returns the argument vectors which run each command in the tree of c with and without its flags.
+func seedArgs(c mycmd.Command) [][]string {
+	seeds := [][]string{{}, {"help"}, {"--help"}}
+	var walk func(c mycmd.Command, path []string)
+	walk = func(c mycmd.Command, path []string) {
+		path = slices.Clip(path)
+		seeds = append(seeds, path, append(path, "--help"), append([]string{"help"}, path...))
+
+		visit := func(f *pflag.Flag) {
+			flag := "--" + f.Name
+			if f.NoOptDefVal != "" {
+				seeds = append(seeds, append(path, flag))
+			} else {
+				seeds = append(seeds, append(path, flag), append(path, flag, "x"), append(path, flag+"="))
+			}
+			if f.Shorthand != "" {
+				seeds = append(seeds, append(path, "-"+f.Shorthand))
+			}
+		}
+		if v, ok := c.(mycmd.PersistentFlagSetSupported); ok {
+			v.PersistentFS().VisitAll(visit)
+		}
+		if v, ok := c.(mycmd.FlagSetSupported); ok {
+			v.FS().VisitAll(visit)
+		}
+
+		if p, ok := c.(mycmd.ParentCommand); ok {
+			for _, sub := range p.Commands() {
+				walk(sub, append(path, sub.Name()))
+			}
+		}
+	}
+	walk(c, []string{})
+	return seeds
+}
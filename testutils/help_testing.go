@@ -0,0 +1,75 @@
+package testutils
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/kmio11/mycmd"
+	"github.com/stretchr/testify/assert"
+)
+
+// RunTestHelpSnapshots walks the tree of the Root made by newCmd, including the hidden commands,
+// and runs a subtest named by the full name of each command, which
+//
+//   - compares Usage() with the golden file golden/<full name>/usage.txt,
+//   - runs "help <path>" and "<path> --help" with ParseAndExecute, and asserts they exit with 0,
+//     print Usage() to the standard output (ignoring the trailing newlines) and print nothing to the error output,
+//   - fails if the command (other than the root) has no ShortDescription.
+func RunTestHelpSnapshots(t *testing.T, testdata *TestData, newCmd Factory) {
+	root, ok := newCmd().(*mycmd.Root)
+	if !ok {
+		t.Fatal("cmd is not Root")
+	}
+
+	var walk func(c mycmd.Command, path []string)
+	walk = func(c mycmd.Command, path []string) {
+		name := strings.Join(mycmd.FullName(c), " ")
+		t.Run(name, func(t *testing.T) {
+			if len(path) > 0 && c.ShortDescription() == "" {
+				t.Errorf("%s has no short description", name)
+			}
+
+			usage := c.Usage()
+			testdata.CompareWithGolden(t, *update,
+				testdata.FileName(t,
+					"golden", strings.ReplaceAll(name, " ", "_"), "usage.txt",
+				),
+				[]byte(usage),
+			)
+
+			for _, args := range [][]string{
+				append([]string{"help"}, path...),
+				append(append([]string{}, path...), "--help"),
+			} {
+				runHelp(t, newCmd, args, usage)
+			}
+		})
+
+		if p, ok := c.(mycmd.ParentCommand); ok {
+			for _, sub := range p.Commands() {
+				walk(sub, append(append([]string{}, path...), sub.Name()))
+			}
+		}
+	}
+	walk(root, []string{})
+}
+
+// runHelp runs the Root made by newCmd with args and asserts that it prints usage.
+func runHelp(t *testing.T, newCmd Factory, args []string, usage string) {
+	t.Helper()
+	root := newCmd().(*mycmd.Root)
+	outWriter, errWriter := new(bytes.Buffer), new(bytes.Buffer)
+	root.SetOutWriter(outWriter)
+	root.SetErrWriter(errWriter)
+	root.SetInReader(strings.NewReader(""))
+
+	actual := root.ParseAndExecute(args)
+
+	assert.Equal(t, 0, actual, "exit code of %q", args)
+	assert.Equal(t,
+		strings.TrimRight(usage, "\n"), strings.TrimRight(outWriter.String(), "\n"),
+		"stdout of %q", args,
+	)
+	assert.Empty(t, errWriter.String(), "stderr of %q", args)
+}
@@ -0,0 +1,59 @@
+package testutils
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Normalizer rewrites the parts of the output which vary between runs, such as paths and timestamps.
+type Normalizer func(data []byte) []byte
+
+// ReplaceString returns the Normalizer which replaces old with new.
+func ReplaceString(old, new string) Normalizer {
+	return func(data []byte) []byte {
+		if old == "" {
+			return data
+		}
+		return []byte(strings.ReplaceAll(string(data), old, new))
+	}
+}
+
+// ReplaceRegexp returns the Normalizer which replaces the matches of re with repl, which can refer to the submatches as regexp.Regexp.ReplaceAll does.
+func ReplaceRegexp(re *regexp.Regexp, repl string) Normalizer {
+	return func(data []byte) []byte {
+		return re.ReplaceAll(data, []byte(repl))
+	}
+}
+
+// NormalizeTempDir returns the Normalizer which replaces the directories made by testing.T.TempDir with $TMPDIR.
+func NormalizeTempDir() Normalizer {
+	// e.g. /tmp/TestName1234567/001
+	return ReplaceRegexp(
+		regexp.MustCompile(regexp.QuoteMeta(filepath.Clean(os.TempDir()))+`[/\\][^/\\\s]+[/\\]\d{3,}`),
+		"$$TMPDIR",
+	)
+}
+
+// NormalizeVersion returns the Normalizer which replaces the semantic versions, e.g. v1.2.3-rc.1, with vX.Y.Z.
+// The "v" prefix is required so that IP addresses and dotted dates, e.g. 127.0.0.1 and 2024.01.02, are kept.
+func NormalizeVersion() Normalizer {
+	return ReplaceRegexp(
+		regexp.MustCompile(`\bv\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?\b`),
+		"vX.Y.Z",
+	)
+}
+
+// NormalizeDuration returns the Normalizer which replaces the durations formatted by time.Duration.String, e.g. 1m2.5s, with <duration>.
+func NormalizeDuration() Normalizer {
+	return ReplaceRegexp(
+		regexp.MustCompile(`\b(\d+(\.\d+)?(h|m|s|ms|µs|us|ns))+\b`),
+		"<duration>",
+	)
+}
+
+// StripANSI returns the Normalizer which removes the ANSI escape sequences, such as colors and cursor movements.
+func StripANSI() Normalizer {
+	return ReplaceRegexp(regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`), "")
+}
@@ -0,0 +1,87 @@
+package testutils
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizers(t *testing.T) {
+	tmp := filepath.Join(filepath.Clean(os.TempDir()), "TestNormalizers1234567", "001")
+
+	tests := []struct {
+		name      string
+		normalize Normalizer
+		in        string
+		want      string
+	}{
+		{
+			name:      "ReplaceString",
+			normalize: ReplaceString("/home/user", "$HOME"),
+			in:        "wrote /home/user/a and /home/user/b",
+			want:      "wrote $HOME/a and $HOME/b",
+		},
+		{
+			name:      "ReplaceString empty old",
+			normalize: ReplaceString("", "x"),
+			in:        "unchanged",
+			want:      "unchanged",
+		},
+		{
+			name:      "ReplaceRegexp with submatch",
+			normalize: ReplaceRegexp(regexp.MustCompile(`pid=(\d+)`), "pid=<$1>"),
+			in:        "started pid=42",
+			want:      "started pid=<42>",
+		},
+		{
+			name:      "NormalizeTempDir",
+			normalize: NormalizeTempDir(),
+			in:        "wrote " + filepath.Join(tmp, "out.txt"),
+			want:      "wrote " + filepath.Join("$TMPDIR", "out.txt"),
+		},
+		{
+			name:      "NormalizeTempDir keeps other directories",
+			normalize: NormalizeTempDir(),
+			in:        "wrote " + filepath.Join(os.TempDir(), "cache", "out.txt"),
+			want:      "wrote " + filepath.Join(os.TempDir(), "cache", "out.txt"),
+		},
+		{
+			name:      "NormalizeVersion",
+			normalize: NormalizeVersion(),
+			in:        "example v1.2.3, v10.20.30-rc.1+build.5 (go v1.21)",
+			want:      "example vX.Y.Z, vX.Y.Z (go v1.21)",
+		},
+		{
+			name:      "NormalizeVersion keeps IP addresses and dates",
+			normalize: NormalizeVersion(),
+			in:        "listening on 127.0.0.1 since 2024.01.02, built by dev1.2.3",
+			want:      "listening on 127.0.0.1 since 2024.01.02, built by dev1.2.3",
+		},
+		{
+			name:      "NormalizeDuration",
+			normalize: NormalizeDuration(),
+			in:        "took 1m2.5s, then 300ms, then 1.5µs and 1h0m0s",
+			want:      "took <duration>, then <duration>, then <duration> and <duration>",
+		},
+		{
+			name:      "NormalizeDuration keeps words",
+			normalize: NormalizeDuration(),
+			in:        "2 modules in 3mins",
+			want:      "2 modules in 3mins",
+		},
+		{
+			name:      "StripANSI",
+			normalize: StripANSI(),
+			in:        "\x1b[1;31mERROR\x1b[0m : failed\r\x1b[K\x1b[?25h",
+			want:      "ERROR : failed\r",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, string(tt.normalize([]byte(tt.in))))
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package testutils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kmio11/mycmd"
+)
+
+// RunTestScripts runs the scenario of each txtar file (*.txtar) in dir as a subtest.
+//
+// The comment of the archive is the script, and the files of the archive are written to a temporary work directory,
+// which is the current directory while the commands are executed and is referred as $WORK.
+// Each line of the script is a command below. Lines beginning with '#' are comments.
+// A command prefixed by '!' is expected to fail.
+//
+//	exec args...          runs the command made by newCmd with args in-process. It must exit with 0 (! exec: non-zero).
+//	status n              asserts the exit code of the last exec.
+//	stdin file            uses the file of the archive as the standard input of the next exec.
+//	stdout regexp         asserts the standard output of the last exec matches regexp (! stdout: doesn't match).
+//	stderr regexp         asserts the error output of the last exec matches regexp (! stderr: doesn't match).
+//	cmp stdout|stderr|path file
+//	                      asserts the output or the file in the work directory equals to the file of the archive.
+//	env key=value         sets the environment variable.
+//	exists path           asserts the file exists in the work directory (! exists: doesn't exist).
+//
+// The arguments are split like a shell does, and $WORK and the environment variables in the arguments of exec and env are expanded.
+// With the -update flag, the files of the archive compared by cmp are rewritten with the actual outputs.
+func RunTestScripts(t *testing.T, dir string, newCmd Factory) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.txtar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("no scripts in %s", dir)
+	}
+
+	for _, file := range files {
+		file := file
+		t.Run(strings.TrimSuffix(filepath.Base(file), ".txtar"), func(t *testing.T) {
+			s := newScript(t, file, newCmd)
+			s.run()
+		})
+	}
+}
+
+type script struct {
+	t       *testing.T
+	file    string
+	archive *txtarArchive
+	newCmd  Factory
+	workDir string
+	line    int
+
+	stdin   string
+	stdout  string
+	stderr  string
+	status  int
+	updated bool
+}
+
+func newScript(t *testing.T, file string, newCmd Factory) *script {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &script{
+		t:       t,
+		file:    file,
+		archive: parseTxtar(data),
+		newCmd:  newCmd,
+		workDir: t.TempDir(),
+	}
+	for _, f := range s.archive.Files {
+		name := filepath.Join(s.workDir, filepath.FromSlash(f.Name))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, f.Data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func (s *script) fatalf(format string, args ...any) {
+	s.t.Helper()
+	s.t.Fatalf("%s:%d: %s", s.file, s.line, fmt.Sprintf(format, args...))
+}
+
+func (s *script) run() {
+	for i, line := range strings.Split(string(s.archive.Comment), "\n") {
+		s.line = i + 1
+		args, err := mycmd.SplitArgs(line)
+		if err != nil {
+			s.fatalf("%s", err)
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		negate := false
+		if args[0] == "!" {
+			negate = true
+			args = args[1:]
+			if len(args) == 0 {
+				s.fatalf("missing command after !")
+			}
+		}
+		s.runCommand(args[0], args[1:], negate)
+	}
+
+	if s.updated {
+		if err := os.WriteFile(s.file, s.archive.format(), 0644); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+}
+
+func (s *script) runCommand(name string, args []string, negate bool) {
+	switch name {
+	case "exec":
+		s.exec(s.expand(args), negate)
+	case "status":
+		s.checkNArgs(name, args, 1, negate)
+		want, err := strconv.Atoi(args[0])
+		if err != nil {
+			s.fatalf("invalid status: %s", args[0])
+		}
+		if s.status != want {
+			s.fatalf("exit code is %d, want %d\nstdout:\n%s\nstderr:\n%s", s.status, want, s.stdout, s.stderr)
+		}
+	case "stdin":
+		s.checkNArgs(name, args, 1, negate)
+		s.stdin = string(s.archiveFile(args[0]).Data)
+	case "stdout":
+		s.checkNArgs(name, args, 1, false)
+		s.match("stdout", s.stdout, args[0], negate)
+	case "stderr":
+		s.checkNArgs(name, args, 1, false)
+		s.match("stderr", s.stderr, args[0], negate)
+	case "cmp":
+		s.checkNArgs(name, args, 2, negate)
+		s.cmp(args[0], args[1])
+	case "env":
+		for _, kv := range s.expand(args) {
+			k, v, ok := strings.Cut(kv, "=")
+			if !ok || negate {
+				s.fatalf("usage: env key=value...")
+			}
+			s.t.Setenv(k, v)
+		}
+	case "exists":
+		s.checkNArgs(name, args, 1, false)
+		_, err := os.Stat(filepath.Join(s.workDir, args[0]))
+		if exists := err == nil; exists == negate {
+			s.fatalf("%s: exists=%v", args[0], exists)
+		}
+	default:
+		s.fatalf("unknown command: %s", name)
+	}
+}
+
+func (s *script) checkNArgs(name string, args []string, n int, negate bool) {
+	s.t.Helper()
+	if negate {
+		s.fatalf("%s cannot be negated", name)
+	}
+	if len(args) != n {
+		s.fatalf("%s needs %d arguments but %d", name, n, len(args))
+	}
+}
+
+func (s *script) expand(args []string) []string {
+	expanded := make([]string, len(args))
+	for i, arg := range args {
+		expanded[i] = os.Expand(arg, func(key string) string {
+			if key == "WORK" {
+				return s.workDir
+			}
+			return os.Getenv(key)
+		})
+	}
+	return expanded
+}
+
+func (s *script) archiveFile(name string) *txtarFile {
+	f, ok := s.archive.file(name)
+	if !ok {
+		s.fatalf("%s is not found in the archive", name)
+	}
+	return f
+}
+
+func (s *script) exec(args []string, negate bool) {
+	wd, err := os.Getwd()
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if err := os.Chdir(s.workDir); err != nil {
+		s.t.Fatal(err)
+	}
+	defer func() {
+		if err := os.Chdir(wd); err != nil {
+			s.t.Fatal(err)
+		}
+	}()
+
+	cmd := s.newCmd()
+	outWriter, errWriter := new(bytes.Buffer), new(bytes.Buffer)
+	cmd.SetOutWriter(outWriter)
+	cmd.SetErrWriter(errWriter)
+	if v, ok := cmd.(mycmd.InReaderSupported); ok {
+		v.SetInReader(strings.NewReader(s.stdin))
+	}
+	s.stdin = ""
+
+	s.status = mycmd.RunCommand(cmd, args)
+	s.stdout, s.stderr = outWriter.String(), errWriter.String()
+
+	if failed := s.status != 0; failed != negate {
+		s.fatalf("exec %s: exit code is %d\nstdout:\n%s\nstderr:\n%s", strings.Join(args, " "), s.status, s.stdout, s.stderr)
+	}
+}
+
+func (s *script) match(name, actual, pattern string, negate bool) {
+	re, err := regexp.Compile("(?m)" + pattern)
+	if err != nil {
+		s.fatalf("invalid regexp: %s", err)
+	}
+	if matched := re.MatchString(actual); matched == negate {
+		if negate {
+			s.fatalf("%s matches %q unexpectedly:\n%s", name, pattern, actual)
+		}
+		s.fatalf("%s doesn't match %q:\n%s", name, pattern, actual)
+	}
+}
+
+func (s *script) cmp(name, expectedFile string) {
+	var actual string
+	switch name {
+	case "stdout":
+		actual = s.stdout
+	case "stderr":
+		actual = s.stderr
+	default:
+		data, err := os.ReadFile(filepath.Join(s.workDir, name))
+		if err != nil {
+			s.fatalf("%s", err)
+		}
+		actual = string(data)
+	}
+
+	// the files of the archive always end with a newline.
+	actual = string(fixTxtarNewline([]byte(actual)))
+
+	expected := s.archiveFile(expectedFile)
+	if string(expected.Data) == actual {
+		return
+	}
+	if *update {
+		expected.Data = []byte(actual)
+		s.updated = true
+		return
+	}
+	s.fatalf("%s and %s differ:\n%s", name, expectedFile, unifiedDiff(expectedFile, name, string(expected.Data), actual))
+}
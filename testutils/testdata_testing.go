@@ -1,25 +1,40 @@
 package testutils
 
 import (
+	"flag"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
+	"sync"
 	"testing"
 
-	"github.com/stretchr/testify/assert"
+	"github.com/pmezard/go-difflib/difflib"
 )
 
 type TestData struct {
-	root string
+	root        string
+	normalizers []Normalizer
+
+	mu       sync.Mutex
+	compared map[string]bool
 }
 
 func NewTestData(t *testing.T, name string) *TestData {
 	return &TestData{
-		root: name,
+		root:     name,
+		compared: map[string]bool{},
 	}
 }
 
+// WithNormalizers adds the normalizers applied to the actual data before comparing with and writing the golden files.
+func (d *TestData) WithNormalizers(normalizers ...Normalizer) *TestData {
+	d.normalizers = append(d.normalizers, normalizers...)
+	return d
+}
+
 func (d *TestData) RootDir(t *testing.T) string {
 	return filepath.Join("testdata", d.root)
 }
@@ -47,12 +62,99 @@ func (d *TestData) ReadFile(t *testing.T, filename string) []byte {
 	return data
 }
 
+// CompareWithGolden compares actual normalized by the normalizers with the golden file,
+// and reports the unified diff if they differ. If update is true, the golden file is rewritten with actual before comparing.
 func (d *TestData) CompareWithGolden(t *testing.T, update bool, goldenFileName string, actual []byte) {
+	t.Helper()
+	for _, normalize := range d.normalizers {
+		actual = normalize(actual)
+	}
+
+	d.markCompared(goldenFileName)
+
 	if update {
 		d.WriteFile(t, goldenFileName, actual)
 	}
 	expected := d.ReadFile(t, goldenFileName)
-	assert.Equal(t, string(expected), string(actual))
+	if diff := unifiedDiff(goldenFileName, "actual", string(expected), string(actual)); diff != "" {
+		t.Errorf("%s differs from the actual (run with -update to update it):\n%s", goldenFileName, diff)
+	}
+}
+
+// markCompared records that the golden file is compared, so that it is not reported by CheckOrphanGoldens.
+func (d *TestData) markCompared(goldenFileName string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.compared[filepath.Clean(goldenFileName)] = true
+}
+
+// unifiedDiff returns the unified diff of a and b, or "" if they are equal.
+func unifiedDiff(aName, bName, a, b string) string {
+	if a == b {
+		return ""
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(a),
+		B:        difflib.SplitLines(b),
+		FromFile: aName,
+		ToFile:   bName,
+		Context:  3,
+	})
+	if err != nil || diff == "" {
+		// the difference is invisible for the line based diff, e.g. the newline at the end.
+		return fmt.Sprintf("--- %s\n%q\n+++ %s\n%q\n", aName, a, bName, b)
+	}
+	return diff
+}
+
+// CheckOrphanGoldens reports the golden files under the root directory which are not compared in the test,
+// which are usually left by the removed or renamed test cases. With the -update flag, they are removed instead.
+// The check is done at the end of the test unless the test failed or the tests are filtered by -run or -skip.
+func (d *TestData) CheckOrphanGoldens(t *testing.T) {
+	t.Cleanup(func() {
+		if t.Failed() || isFiltered() {
+			return
+		}
+		for _, orphan := range d.orphanGoldens(t) {
+			if *update {
+				if err := os.Remove(orphan); err != nil {
+					t.Error(err)
+				}
+				continue
+			}
+			t.Errorf("%s is not compared by any test case (run with -update to remove it)", orphan)
+		}
+	})
+}
+
+func (d *TestData) orphanGoldens(t *testing.T) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	orphans := []string{}
+	err := filepath.WalkDir(d.FileName(t, "golden"), func(path string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !e.IsDir() && !d.compared[filepath.Clean(path)] {
+			orphans = append(orphans, path)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		t.Error(err)
+	}
+	sort.Strings(orphans)
+	return orphans
+}
+
+func isFiltered() bool {
+	for _, name := range []string{"test.run", "test.skip"} {
+		if f := flag.Lookup(name); f != nil && f.Value.String() != "" {
+			return true
+		}
+	}
+	return false
 }
 
 // TempDirInTestdata makes temp directory for test.
@@ -0,0 +1,80 @@
+package testutils
+
+import (
+	"bytes"
+	"strings"
+)
+
+// txtarArchive is a minimal implementation of the txtar format (golang.org/x/tools/txtar).
+// The comment is followed by the files, each of which begins with a "-- name --" line.
+type txtarArchive struct {
+	Comment []byte
+	Files   []txtarFile
+}
+
+type txtarFile struct {
+	Name string
+	Data []byte
+}
+
+func parseTxtar(data []byte) *txtarArchive {
+	a := &txtarArchive{}
+	var name string
+	a.Comment, name, data = findTxtarFileMarker(data)
+	for name != "" {
+		f := txtarFile{Name: name}
+		f.Data, name, data = findTxtarFileMarker(data)
+		a.Files = append(a.Files, f)
+	}
+	return a
+}
+
+// findTxtarFileMarker returns the data before the next file marker, the name of the file and the data after the marker.
+func findTxtarFileMarker(data []byte) (before []byte, name string, after []byte) {
+	var i int
+	for {
+		if name, after = isTxtarFileMarker(data[i:]); name != "" {
+			return data[:i], name, after
+		}
+		j := bytes.IndexByte(data[i:], '\n')
+		if j < 0 {
+			return fixTxtarNewline(data), "", nil
+		}
+		i += j + 1
+	}
+}
+
+func isTxtarFileMarker(data []byte) (name string, after []byte) {
+	line, after, _ := bytes.Cut(data, []byte("\n"))
+	s := strings.TrimRight(string(line), "\r")
+	if !strings.HasPrefix(s, "-- ") || !strings.HasSuffix(s, " --") || len(s) < len("-- x --") {
+		return "", nil
+	}
+	return strings.TrimSpace(s[len("-- ") : len(s)-len(" --")]), after
+}
+
+func fixTxtarNewline(data []byte) []byte {
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		return append(data, '\n')
+	}
+	return data
+}
+
+func (a *txtarArchive) file(name string) (*txtarFile, bool) {
+	for i := range a.Files {
+		if a.Files[i].Name == name {
+			return &a.Files[i], true
+		}
+	}
+	return nil, false
+}
+
+func (a *txtarArchive) format() []byte {
+	var buf bytes.Buffer
+	buf.Write(fixTxtarNewline(a.Comment))
+	for _, f := range a.Files {
+		buf.WriteString("-- " + f.Name + " --\n")
+		buf.Write(fixTxtarNewline(f.Data))
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,34 @@
+package mycmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HelpTopic is a help document which is not a command,
+// such as the description of the environment variables or the configuration files.
+// It is shown by the help command of the ParentBase which registers it, e.g. 'example help environment'.
+type HelpTopic struct {
+	Name             string
+	ShortDescription string
+	Long             string
+}
+
+// Usage returns the long text of the topic.
+func (t HelpTopic) Usage() string {
+	return fmt.Sprintf("\n%s\n", strings.Trim(t.Long, "\n"))
+}
+
+// findHelpTopic returns the help topic of p named name, or nil.
+func findHelpTopic(p Command, name string) *HelpTopic {
+	v, ok := p.(HelpTopicsSupported)
+	if !ok {
+		return nil
+	}
+	for _, topic := range v.HelpTopics() {
+		if topic.Name == name {
+			return &topic
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package mycmd
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kmio11/mycmd/wflag"
+	"github.com/spf13/pflag"
+)
+
+// TreeOptions configures Tree.
+type TreeOptions struct {
+	// Depth limits the depth of the listed commands. 0 means no limit.
+	Depth int
+	// Hidden includes the hidden commands with the "(hidden)" marker.
+	Hidden bool
+	// Flags appends the names of the flags to the short description of each command.
+	Flags bool
+}
+
+type treeLine struct {
+	head        string
+	description string
+}
+
+// Tree returns the indented tree of the commands under c with their short descriptions.
+func Tree(c Command, opts TreeOptions) string {
+	lines := []treeLine{{
+		head:        strings.Join(FullName(c), " "),
+		description: treeDescription(c, opts),
+	}}
+	lines = append(lines, treeLines(c, "", 1, opts)...)
+
+	width := 0
+	for _, line := range lines {
+		if n := utf8.RuneCountInString(line.head); width < n {
+			width = n
+		}
+	}
+
+	var b strings.Builder
+	for _, line := range lines {
+		if line.description == "" {
+			fmt.Fprintln(&b, line.head)
+			continue
+		}
+		spacing := strings.Repeat(" ", width-utf8.RuneCountInString(line.head)+3)
+		fmt.Fprintf(&b, "%s%s%s\n", line.head, spacing, line.description)
+	}
+	return b.String()
+}
+
+func treeLines(c Command, indent string, depth int, opts TreeOptions) []treeLine {
+	parent, ok := c.(ParentCommand)
+	if !ok || (opts.Depth > 0 && depth > opts.Depth) {
+		return nil
+	}
+
+	subs := []Command{}
+	for _, sub := range parent.Commands() {
+		if isHidden(sub) && !opts.Hidden {
+			continue
+		}
+		subs = append(subs, sub)
+	}
+
+	lines := []treeLine{}
+	for i, sub := range subs {
+		branch, next := "├── ", "│   "
+		if i == len(subs)-1 {
+			branch, next = "└── ", "    "
+		}
+		name := sub.Name()
+		if isHidden(sub) {
+			name += " (hidden)"
+		}
+		lines = append(lines, treeLine{
+			head:        indent + branch + name,
+			description: treeDescription(sub, opts),
+		})
+		lines = append(lines, treeLines(sub, indent+next, depth+1, opts)...)
+	}
+	return lines
+}
+
+func treeDescription(c Command, opts TreeOptions) string {
+	description := c.ShortDescription()
+	if !opts.Flags {
+		return description
+	}
+	names := localFlagNames(c)
+	if len(names) == 0 {
+		return description
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s [%s]", description, strings.Join(names, " ")))
+}
+
+func isHidden(c Command) bool {
+	v, ok := c.(HiddenSupported)
+	return ok && v.Hidden()
+}
+
+// localFlagNames returns the names of the visible flags defined by c, excluding the ones inherited from its parents.
+func localFlagNames(c Command) []string {
+	sets := []*wflag.FlagSet{}
+	if v, ok := c.(PersistentFlagSetSupported); ok {
+		sets = append(sets, v.PersistentFS())
+	}
+	if v, ok := c.(FlagSetSupported); ok {
+		sets = append(sets, v.FS())
+	}
+	var parent Command
+	if v, ok := c.(SubCommand); ok {
+		parent = v.Parent()
+	}
+	inherited := inheritedFlags(parent)
+
+	names := []string{}
+	seen := map[string]bool{}
+	for _, fs := range sets {
+		fs.VisitAll(func(f *pflag.Flag) {
+			if f.Hidden || seen[f.Name] || inherited.Lookup(f.Name) == f {
+				return
+			}
+			seen[f.Name] = true
+			names = append(names, "--"+f.Name)
+		})
+	}
+	return names
+}
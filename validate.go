@@ -0,0 +1,206 @@
+package mycmd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	fv "github.com/kmio11/flag-validator/pflag-validator"
+	"github.com/kmio11/mycmd/wflag"
+	"github.com/spf13/pflag"
+)
+
+// Validate checks the tree of root and returns all the problems found joined by errors.Join, or nil if there are none.
+// The problems are
+//   - subcommands of the same parent sharing a name or an alias,
+//   - subcommands named or aliased "help", which are shadowed by the built-in help command,
+//   - help topics shadowed by the subcommands,
+//   - flags whose shorthand conflicts with a persistent flag inherited from the parents,
+//   - validation rules referring to the flags which are not defined for the command.
+//
+// The validation rules are opaque, so they are checked by evaluating them with none and all of the flags set.
+// The references in the conditions which never hold, or hidden by custom error messages, are not found.
+//
+// Validate is intended to be called in a test. It is also called by ParseAndExecute of Root in the builds with the mycmd_debug tag.
+func Validate(root Command) error {
+	v := &validator{}
+	v.walk(root, nil)
+	return errors.Join(v.problems...)
+}
+
+type validator struct {
+	problems []error
+}
+
+func (v *validator) errorf(c Command, format string, args ...any) {
+	v.problems = append(v.problems,
+		fmt.Errorf("%s: %s", strings.Join(FullName(c), " "), fmt.Sprintf(format, args...)),
+	)
+}
+
+// walk validates c and its descendants. inherited are the persistent flags of the parents of c.
+func (v *validator) walk(c Command, inherited []*pflag.Flag) {
+	if p, ok := c.(PersistentFlagSetSupported); ok {
+		p.PersistentFS().VisitAll(func(f *pflag.Flag) {
+			v.checkShorthand(c, f, inherited)
+		})
+		p.PersistentFS().VisitAll(func(f *pflag.Flag) {
+			inherited = appendFlag(inherited, f)
+		})
+	}
+
+	if fs, ok := c.(FlagSetSupported); ok {
+		// the own flags take precedence over the inherited ones.
+		available := []*pflag.Flag{}
+		fs.FS().VisitAll(func(f *pflag.Flag) {
+			v.checkShorthand(c, f, inherited)
+			available = appendFlag(available, f)
+		})
+		for _, f := range inherited {
+			available = appendFlag(available, f)
+		}
+		v.checkRules(c, fs.FS(), available)
+	}
+
+	if p, ok := c.(ParentCommand); ok {
+		v.checkNames(p)
+		for _, sub := range p.Commands() {
+			v.walk(sub, inherited)
+		}
+	}
+}
+
+// appendFlag appends f to flags unless the flag of the same name is in flags,
+// which takes precedence like mergeFlags.
+func appendFlag(flags []*pflag.Flag, f *pflag.Flag) []*pflag.Flag {
+	for _, g := range flags {
+		if g.Name == f.Name {
+			return flags
+		}
+	}
+	return append(flags[:len(flags):len(flags)], f)
+}
+
+// checkShorthand reports f whose shorthand is used by another inherited flag,
+// which makes the inherited flag unavailable to c.
+func (v *validator) checkShorthand(c Command, f *pflag.Flag, inherited []*pflag.Flag) {
+	if f.Shorthand == "" {
+		return
+	}
+	for _, g := range inherited {
+		if g.Shorthand == f.Shorthand && g.Name != f.Name {
+			v.errorf(c, "shorthand -%s of flag --%s conflicts with the inherited flag --%s", f.Shorthand, f.Name, g.Name)
+		}
+	}
+}
+
+// checkNames reports the names and the aliases of the subcommands and the help topics of p which are not reachable,
+// and the subcommands added to p more than once.
+func (v *validator) checkNames(p ParentCommand) {
+	const helpName = "help"
+	owners := map[string]Command{}
+	added := []Command{}
+	for _, sub := range p.Commands() {
+		if slices.Contains(added, sub) {
+			v.errorf(p, "%s is added more than once", sub.Name())
+			continue
+		}
+		added = append(added, sub)
+
+		names := []string{sub.Name()}
+		if a, ok := sub.(AliasesSupported); ok {
+			names = append(names, a.Aliases()...)
+		}
+		for _, name := range names {
+			if name == helpName {
+				v.errorf(p, "%q of %s is shadowed by the built-in help command", name, sub.Name())
+				continue
+			}
+			if owner, ok := owners[name]; ok && owner != sub {
+				v.errorf(p, "%q is used by both %s and %s", name, owner.Name(), sub.Name())
+				continue
+			}
+			owners[name] = sub
+		}
+	}
+
+	t, ok := p.(HelpTopicsSupported)
+	if !ok {
+		return
+	}
+	for _, topic := range t.HelpTopics() {
+		if owner, ok := owners[topic.Name]; ok {
+			v.errorf(p, "help topic %q is shadowed by %s", topic.Name, owner.Name())
+		}
+	}
+}
+
+// flagReference matches the flags in the messages of the validation errors, e.g. "The flag [--out] is required".
+var flagReference = regexp.MustCompile(`--([0-9A-Za-z][-_0-9A-Za-z]*)`)
+
+// checkRules evaluates the validation rules of fs against the probes having the available flags,
+// and reports the rules which panic or whose errors refer to the undefined flags.
+func (v *validator) checkRules(c Command, fs *wflag.FlagSet, available []*pflag.Flag) {
+	for i, rule := range fs.ValidationRules() {
+		for _, setAll := range []bool{false, true} {
+			probe := newProbeFlagSet(c.Name(), available, setAll)
+			panicked, err := validateRecovered(rule, probe)
+			if panicked != nil {
+				v.errorf(c, "validation rule %d panics: %v", i+1, panicked)
+				break
+			}
+			if err == nil || !setAll {
+				continue
+			}
+			// every defined flag is set, so the flags in the error are not defined.
+			for _, m := range flagReference.FindAllStringSubmatch(err.Error(), -1) {
+				if probe.Lookup(m[1]) == nil {
+					v.errorf(c, "validation rule %d refers to undefined flag --%s", i+1, m[1])
+				}
+			}
+		}
+	}
+}
+
+func validateRecovered(rule fv.Rule, fs *pflag.FlagSet) (panicked any, err error) {
+	defer func() {
+		panicked = recover()
+	}()
+	return nil, rule.Validate(fs)
+}
+
+// newProbeFlagSet returns the FlagSet which has the copies of flags, so that the rules can be evaluated without changing the flags.
+// The shorthands are dropped since they may conflict, which is reported by checkShorthand.
+func newProbeFlagSet(name string, flags []*pflag.Flag, setAll bool) *pflag.FlagSet {
+	probe := pflag.NewFlagSet(name, pflag.ContinueOnError)
+	for _, f := range flags {
+		probe.AddFlag(&pflag.Flag{
+			Name:        f.Name,
+			Usage:       f.Usage,
+			Value:       &probeValue{typ: f.Value.Type(), value: f.DefValue},
+			DefValue:    f.DefValue,
+			NoOptDefVal: f.NoOptDefVal,
+		})
+		if setAll {
+			_ = probe.Set(f.Name, f.DefValue)
+		}
+	}
+	return probe
+}
+
+// probeValue is a flag value of any type, which accepts any string.
+type probeValue struct {
+	typ   string
+	value string
+}
+
+func (v *probeValue) String() string { return v.value }
+
+func (v *probeValue) Set(s string) error {
+	v.value = s
+	return nil
+}
+
+func (v *probeValue) Type() string { return v.typ }
@@ -0,0 +1,215 @@
+package wflag
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Bind defines the flags and arguments from the tags of the fields of the struct pointed by opts.
+// The fields are populated when the FlagSet is parsed.
+//
+// The following tags are supported.
+//
+//	flag:"name,n"      defines the flag --name with the shorthand -n.
+//	arg:"0,name"       binds the 0th non-flag argument, which must be a string field.
+//	usage:"..."        the usage of the flag or the argument.
+//	default:"..."      the default value of the flag. The current value of the field is used if omitted.
+//	required:"true"    marks the flag or the argument as required.
+//	env:"NAME"         the environment variable used when the flag is not specified.
+//	enum:"a,b"         restricts the value of the string flag.
+//	prefix:"group-"    the prefix of the flag names in the nested struct.
+//
+// Nested structs (and pointers to structs) without flag tag are bound recursively as a group of flags.
+// The supported types are string, bool, int, int64, uint, uint64, float64, time.Duration, []string and []int.
+func (fs *FlagSet) Bind(opts any) error {
+	v := reflect.ValueOf(opts)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("opts must be a pointer to struct, but %T", opts)
+	}
+	return fs.bindStruct(v.Elem(), "")
+}
+
+// MustBind is like Bind but panics if an error occurs.
+func (fs *FlagSet) MustBind(opts any) {
+	if err := fs.Bind(opts); err != nil {
+		panic(err)
+	}
+}
+
+func (fs *FlagSet) bindStruct(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+
+		if tag, ok := field.Tag.Lookup("arg"); ok {
+			if err := fs.bindArg(field, fv, tag); err != nil {
+				return err
+			}
+			continue
+		}
+
+		tag, ok := field.Tag.Lookup("flag")
+		if !ok {
+			// a group of flags
+			if field.Type.Kind() == reflect.Pointer && field.Type.Elem().Kind() == reflect.Struct {
+				if fv.IsNil() {
+					fv.Set(reflect.New(field.Type.Elem()))
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(time.Time{}) {
+				if err := fs.bindStruct(fv, prefix+field.Tag.Get("prefix")); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if err := fs.bindFlag(field, fv, prefix, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fs *FlagSet) bindFlag(field reflect.StructField, v reflect.Value, prefix string, tag string) error {
+	name, shorthand, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	name = prefix + name
+	usage := field.Tag.Get("usage")
+	env := field.Tag.Get("env")
+	if env != "" {
+		usage = fmt.Sprintf("%s (env $%s)", usage, env)
+	}
+
+	if def, ok := field.Tag.Lookup("default"); ok {
+		if err := setField(v, def); err != nil {
+			return fmt.Errorf("invalid default of %s: %w", field.Name, err)
+		}
+	}
+
+	switch p := v.Addr().Interface().(type) {
+	case *string:
+		if enum, ok := field.Tag.Lookup("enum"); ok {
+			fs.VarP(&enumValue{value: p, def: *p, choices: strings.Split(enum, ",")}, name, shorthand, usage)
+			break
+		}
+		fs.StringVarP(p, name, shorthand, *p, usage)
+	case *bool:
+		fs.BoolVarP(p, name, shorthand, *p, usage)
+	case *int:
+		fs.IntVarP(p, name, shorthand, *p, usage)
+	case *int64:
+		fs.Int64VarP(p, name, shorthand, *p, usage)
+	case *uint:
+		fs.UintVarP(p, name, shorthand, *p, usage)
+	case *uint64:
+		fs.Uint64VarP(p, name, shorthand, *p, usage)
+	case *float64:
+		fs.Float64VarP(p, name, shorthand, *p, usage)
+	case *time.Duration:
+		fs.DurationVarP(p, name, shorthand, *p, usage)
+	case *[]string:
+		fs.StringSliceVarP(p, name, shorthand, *p, usage)
+	case *[]int:
+		fs.IntSliceVarP(p, name, shorthand, *p, usage)
+	default:
+		return fmt.Errorf("unsupported type of flag %s: %s", field.Name, field.Type)
+	}
+
+	if env != "" {
+		fs.envs[name] = env
+	}
+	if field.Tag.Get("required") == "true" {
+		return fs.MarkRequired(name)
+	}
+	return nil
+}
+
+func (fs *FlagSet) bindArg(field reflect.StructField, v reflect.Value, tag string) error {
+	idx, name, _ := strings.Cut(tag, ",")
+	n, err := strconv.Atoi(idx)
+	if err != nil {
+		return fmt.Errorf("invalid arg tag of %s: %s", field.Name, tag)
+	}
+	p, ok := v.Addr().Interface().(*string)
+	if !ok {
+		return fmt.Errorf("unsupported type of argument %s: %s", field.Name, field.Type)
+	}
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+
+	fs.ArgStringVar(p, n, name, field.Tag.Get("usage"))
+	if field.Tag.Get("required") == "true" {
+		return fs.MarkArgRequired(n)
+	}
+	return nil
+}
+
+func setField(v reflect.Value, s string) error {
+	switch p := v.Addr().Interface().(type) {
+	case *time.Duration:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		*p = d
+		return nil
+	case *[]string:
+		*p = strings.Split(s, ",")
+		return nil
+	case *[]int:
+		values := []int{}
+		for _, e := range strings.Split(s, ",") {
+			i, err := strconv.Atoi(e)
+			if err != nil {
+				return err
+			}
+			values = append(values, i)
+		}
+		*p = values
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type: %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package wflag
+
+import (
+	"testing"
+	"time"
+
+	flag "github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+type bindOptions struct {
+	Out     string        `flag:"out,o" usage:"output file" required:"true" env:"BIND_TEST_OUT"`
+	Format  string        `flag:"format" usage:"format" default:"json" enum:"json,yaml"`
+	Timeout time.Duration `flag:"timeout" usage:"timeout" default:"3s"`
+	Tags    []string      `flag:"tag" usage:"tags"`
+	DB      struct {
+		Host string `flag:"host" usage:"database host" default:"localhost"`
+		Port int    `flag:"port" usage:"database port" default:"5432"`
+	} `prefix:"db-"`
+	Package string `arg:"0,package" usage:"the package" required:"true"`
+	ignored string
+}
+
+func TestFlagSet_Bind(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		env     string
+		want    bindOptions
+		wantErr bool
+	}{
+		{
+			name: "flags and arguments",
+			args: []string{"-o", "out", "--format", "yaml", "--tag", "a,b", "--db-port", "3306", "pkg"},
+			want: func() bindOptions {
+				o := bindOptions{Out: "out", Format: "yaml", Timeout: 3 * time.Second, Tags: []string{"a", "b"}, Package: "pkg"}
+				o.DB.Host, o.DB.Port = "localhost", 3306
+				return o
+			}(),
+		},
+		{
+			name: "environment variable",
+			args: []string{"pkg"},
+			env:  "from-env",
+			want: func() bindOptions {
+				o := bindOptions{Out: "from-env", Format: "json", Timeout: 3 * time.Second, Package: "pkg"}
+				o.DB.Host, o.DB.Port = "localhost", 5432
+				return o
+			}(),
+		},
+		{
+			name:    "missing required flag",
+			args:    []string{"pkg"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid enum",
+			args:    []string{"-o", "out", "--format", "xml", "pkg"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env != "" {
+				t.Setenv("BIND_TEST_OUT", tt.env)
+			}
+			fs := NewFlagSet("test", flag.ContinueOnError)
+			var opts bindOptions
+			assert.NoError(t, fs.Bind(&opts))
+
+			err := fs.Parse(tt.args)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, opts)
+		})
+	}
+}
+
+func TestFlagSet_Bind_error(t *testing.T) {
+	fs := NewFlagSet("test", flag.ContinueOnError)
+	assert.Error(t, fs.Bind(bindOptions{}))
+	assert.Error(t, fs.Bind(&struct {
+		C chan int `flag:"c"`
+	}{}))
+	assert.Error(t, fs.Bind(&struct {
+		N int `arg:"0,n"`
+	}{}))
+}
+
+func TestFlagSet_Bind_requiredArg(t *testing.T) {
+	opts := &struct {
+		Packages string `arg:"0,packages" required:"true"`
+	}{}
+	fs := NewFlagSet("test", flag.ContinueOnError)
+	assert.NoError(t, fs.Bind(opts))
+
+	assert.EqualError(t, fs.Parse([]string{}), "The argument [packages] is required")
+
+	fs.Reset()
+	assert.NoError(t, fs.Parse([]string{"./..."}))
+	assert.Equal(t, "./...", opts.Packages)
+}
@@ -0,0 +1,116 @@
+package wflag
+
+import (
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"unsafe"
+
+	flag "github.com/spf13/pflag"
+)
+
+// defaultValue is the saved state of a flag value, which is restored by Reset.
+type defaultValue struct {
+	// elem is the copy of the value which the flag.Value points to.
+	elem reflect.Value
+	// pointees are the copies of the variables which the pointer fields of the value point to, by the field index.
+	// e.g. the variable passed to StringSliceVar.
+	pointees map[int]reflect.Value
+}
+
+// saveDefaults saves the values of the flags which are not saved yet.
+// The flags which are already changed are restored from DefValue before saving,
+// because their default values are lost.
+func (fs *FlagSet) saveDefaults() {
+	fs.FlagSet.VisitAll(func(f *flag.Flag) {
+		if _, ok := fs.defaults[f.Name]; ok {
+			return
+		}
+		if f.Changed {
+			setDefValue(f)
+		}
+		if d, ok := saveValue(f.Value); ok {
+			fs.defaults[f.Name] = d
+		}
+	})
+}
+
+// resetFlag restores f to the saved default value.
+func (fs *FlagSet) resetFlag(f *flag.Flag) {
+	f.Changed = false
+	d, ok := fs.defaults[f.Name]
+	if !ok {
+		setDefValue(f)
+		return
+	}
+	elem := reflect.ValueOf(f.Value).Elem()
+	elem.Set(clone(d.elem))
+	for i, p := range d.pointees {
+		settable(elem.Field(i)).Elem().Set(clone(p))
+	}
+}
+
+// saveValue copies the state of v, so that the slice and map values forget that they are set,
+// and do not append the values to the default ones on the next parsing.
+func saveValue(v flag.Value) (defaultValue, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return defaultValue{}, false
+	}
+	elem := rv.Elem()
+	d := defaultValue{elem: clone(elem), pointees: map[int]reflect.Value{}}
+	if elem.Kind() == reflect.Struct {
+		for i := 0; i < elem.NumField(); i++ {
+			p := settable(elem.Field(i))
+			if p.Kind() == reflect.Pointer && !p.IsNil() {
+				d.pointees[i] = clone(p.Elem())
+			}
+		}
+	}
+	return d, true
+}
+
+// setDefValue sets DefValue to f, which is used if the value can not be saved.
+func setDefValue(f *flag.Flag) {
+	sv, ok := f.Value.(flag.SliceValue)
+	if !ok {
+		_ = f.Value.Set(f.DefValue)
+		return
+	}
+	def := strings.TrimSuffix(strings.TrimPrefix(f.DefValue, "["), "]")
+	if def == "" {
+		_ = sv.Replace([]string{})
+		return
+	}
+	// the slice values are written in CSV, e.g. ["a,b",c].
+	vals, err := csv.NewReader(strings.NewReader(def)).Read()
+	if err != nil {
+		return
+	}
+	_ = sv.Replace(vals)
+}
+
+// clone returns the copy of v, which does not share the elements of the slices and the maps.
+func clone(v reflect.Value) reflect.Value {
+	switch {
+	case v.Kind() == reflect.Slice && !v.IsNil():
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(c, v)
+		return c
+	case v.Kind() == reflect.Map && !v.IsNil():
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), iter.Value())
+		}
+		return c
+	}
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	return c
+}
+
+// settable returns the addressable field v, which can be set even if it is unexported.
+func settable(v reflect.Value) reflect.Value {
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
+	"sort"
 	"strings"
 
 	fv "github.com/kmio11/flag-validator/pflag-validator"
@@ -19,9 +21,21 @@ var ErrHelp = errors.New("help requested")
 type FlagSet struct {
 	*flag.FlagSet
 	args            map[int]Arg
-	validationRules *fv.RuleSet
+	validationRules []fv.Rule
+	addedRules      []fv.Rule
+	required        []string
+	flagPrompts     map[string]Prompt
+	envs            map[string]string
+	prompter        *Prompter
+	flagSources     map[string]Source
+	argSources      map[int]Source
+	defaults        map[string]defaultValue
+	// parsing is the FlagSet used by the last parsing, which has the flags of fs and the inherited ones.
+	parsing *flag.FlagSet
 
+	name          string
 	errorHandling flag.ErrorHandling
+	interspersed  bool
 }
 
 // Arg represents non-flag arguments.
@@ -30,6 +44,9 @@ type Arg struct {
 	Name  string
 	Usage string
 	Value *string
+
+	Required bool
+	Prompt   *Prompt
 }
 
 func NewFlagSet(name string, errorHandling flag.ErrorHandling) *FlagSet {
@@ -41,8 +58,52 @@ func NewFlagSet(name string, errorHandling flag.ErrorHandling) *FlagSet {
 	return &FlagSet{
 		FlagSet:       fs,
 		args:          map[int]Arg{},
+		flagPrompts:   map[string]Prompt{},
+		envs:          map[string]string{},
+		flagSources:   map[string]Source{},
+		argSources:    map[int]Source{},
+		defaults:      map[string]defaultValue{},
+		name:          name,
 		errorHandling: errorHandling,
+		interspersed:  true,
+	}
+}
+
+// SetInterspersed sets whether to support interspersed option/non-option arguments.
+func (fs *FlagSet) SetInterspersed(interspersed bool) {
+	fs.interspersed = interspersed
+	fs.FlagSet.SetInterspersed(interspersed)
+}
+
+// Reset restores all flags and arguments to their default values,
+// so that the FlagSet can be parsed again as if it were never parsed.
+// The default values are the values of the flags when fs is parsed or reset first.
+func (fs *FlagSet) Reset() {
+	old := fs.FlagSet
+
+	// pflag.FlagSet has no way to forget the flags set by the previous parsing,
+	// so the flags are moved to a new one.
+	fresh := flag.NewFlagSet(fs.name, fs.errorHandling)
+	fresh.Usage = old.Usage
+	fresh.SortFlags = old.SortFlags
+	fresh.ParseErrorsWhitelist = old.ParseErrorsWhitelist
+	fresh.SetOutput(io.Discard)
+	fresh.SetNormalizeFunc(old.GetNormalizeFunc())
+	fresh.SetInterspersed(fs.interspersed)
+
+	fs.saveDefaults()
+	old.VisitAll(func(f *flag.Flag) {
+		fs.resetFlag(f)
+		fresh.AddFlag(f)
+	})
+	fs.FlagSet = fresh
+
+	for _, a := range fs.args {
+		*a.Value = ""
 	}
+	fs.flagSources = map[string]Source{}
+	fs.argSources = map[int]Source{}
+	fs.parsing = nil
 }
 
 func (fs *FlagSet) FlagUsages() string {
@@ -55,9 +116,16 @@ func (fs *FlagSet) ArgUsages() string {
 		spacingNum = 3
 		adjuster   = "\x00"
 	)
+	indexes := []int{}
+	for i := range fs.args {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
 	lines := []string{}
 	var maxNameLen int
-	for _, arg := range fs.args {
+	for _, i := range indexes {
+		arg := fs.args[i]
 		if len(arg.Name) > maxNameLen {
 			maxNameLen = len(arg.Name)
 		}
@@ -87,23 +155,61 @@ func (fs *FlagSet) ArgUsages() string {
 }
 
 func (fs *FlagSet) Parse(args []string) error {
-	err := fs.FlagSet.Parse(args)
+	return fs.ParseWithInherited(args, nil)
+}
+
+// ParseWithInherited is like Parse, but also accepts the flags of inherited, e.g. the persistent flags of the parent commands.
+// The flags of fs take precedence, and the inherited flags whose shorthands are used by fs are not available.
+// The inherited flags are not added to fs, but the values are set to them.
+// The validation rules are evaluated with both of the flags.
+func (fs *FlagSet) ParseWithInherited(args []string, inherited *flag.FlagSet) error {
+	fs.saveDefaults()
+	fs.parsing = fs.newParsingSet(inherited)
+	err := fs.parsing.Parse(args)
 	if err != nil {
 		if errors.Is(err, flag.ErrHelp) {
 			return ErrHelp
 		}
 		return err
 	}
+	fs.parsing.Visit(func(f *flag.Flag) {
+		fs.flagSources[f.Name] = Source{Kind: SourceCommandLine}
+	})
+	err = fs.applyEnvs()
+	if err != nil {
+		return err
+	}
+	if fs.prompter.enabled() {
+		err = fs.promptMissing()
+		if err != nil {
+			return err
+		}
+	}
 	for i, a := range fs.args {
 		if i > fs.NArg()-1 {
 			*a.Value = ""
+			delete(fs.argSources, i)
 			continue
 		}
 		iArg := fs.Arg(i)
 		*a.Value = iArg
+		if _, ok := fs.argSources[i]; !ok {
+			fs.argSources[i] = Source{Kind: SourceCommandLine}
+		}
+	}
+	for _, name := range fs.required {
+		err = fv.Flag(name).Required().Validate(fs.parsing)
+		if err != nil {
+			return fs.handleParsingError(err)
+		}
+	}
+	for _, a := range fs.Arguments() {
+		if a.Required && a.Index >= fs.NArg() {
+			return fs.handleParsingError(fmt.Errorf("The argument [%s] is required", a.Name))
+		}
 	}
-	if fs.validationRules != nil {
-		err = fs.validationRules.Validate(fs.FlagSet)
+	for _, rule := range fs.ValidationRules() {
+		err = rule.Validate(fs.parsing)
 		if err != nil {
 			return fs.handleParsingError(err)
 		}
@@ -112,6 +218,70 @@ func (fs *FlagSet) Parse(args []string) error {
 	return nil
 }
 
+// newParsingSet returns the FlagSet which has the flags of fs followed by the inherited ones.
+func (fs *FlagSet) newParsingSet(inherited *flag.FlagSet) *flag.FlagSet {
+	ps := flag.NewFlagSet(fs.name, fs.errorHandling)
+	ps.Usage = fs.FlagSet.Usage
+	ps.SortFlags = fs.FlagSet.SortFlags
+	ps.ParseErrorsWhitelist = fs.FlagSet.ParseErrorsWhitelist
+	ps.SetOutput(io.Discard)
+	ps.SetNormalizeFunc(fs.FlagSet.GetNormalizeFunc())
+	ps.SetInterspersed(fs.interspersed)
+
+	fs.FlagSet.VisitAll(ps.AddFlag)
+	if inherited != nil {
+		inherited.VisitAll(func(f *flag.Flag) {
+			if ps.Lookup(f.Name) != nil {
+				return
+			}
+			if f.Shorthand != "" && ps.ShorthandLookup(f.Shorthand) != nil {
+				return
+			}
+			ps.AddFlag(f)
+		})
+	}
+	return ps
+}
+
+// working returns the FlagSet used by the last parsing, or the FlagSet of the own flags if it is not parsed.
+func (fs *FlagSet) working() *flag.FlagSet {
+	if fs.parsing != nil {
+		return fs.parsing
+	}
+	return fs.FlagSet
+}
+
+// Args returns the non-flag arguments of the last parsing.
+func (fs *FlagSet) Args() []string {
+	return fs.working().Args()
+}
+
+// NArg returns the number of the non-flag arguments of the last parsing.
+func (fs *FlagSet) NArg() int {
+	return fs.working().NArg()
+}
+
+// Arg returns i'th non-flag argument of the last parsing, or an empty string if it doesn't exist.
+func (fs *FlagSet) Arg(i int) string {
+	return fs.working().Arg(i)
+}
+
+// Parsed reports whether fs is parsed since it is made or reset.
+func (fs *FlagSet) Parsed() bool {
+	return fs.parsing != nil
+}
+
+// Set sets the value of the named flag, including the inherited flags after parsing.
+func (fs *FlagSet) Set(name, value string) error {
+	return fs.working().Set(name, value)
+}
+
+// Visit visits the flags set by the last parsing or Set in lexicographical order, or in primordial order if SortFlags is false.
+// The inherited flags are also visited.
+func (fs *FlagSet) Visit(fn func(*flag.Flag)) {
+	fs.working().Visit(fn)
+}
+
 func (fs *FlagSet) handleParsingError(err error) error {
 	if err != nil {
 		switch fs.errorHandling {
@@ -128,17 +298,73 @@ func (fs *FlagSet) handleParsingError(err error) error {
 }
 
 func (fs *FlagSet) SetValidationRules(rules ...fv.Rule) {
-	fs.validationRules = fv.NewRuleSet(rules...)
+	fs.validationRules = rules
+}
+
+// AddValidationRules adds the rules which are validated after the rules set by SetValidationRules.
+// It is useful for the helpers which define flags with their own rules.
+func (fs *FlagSet) AddValidationRules(rules ...fv.Rule) {
+	fs.addedRules = append(fs.addedRules, rules...)
+}
+
+// ValidationRules returns the rules set by SetValidationRules followed by the ones added by AddValidationRules.
+func (fs *FlagSet) ValidationRules() []fv.Rule {
+	return append(slices.Clip(fs.validationRules), fs.addedRules...)
+}
+
+// SetEnv makes the named flag take the value of the environment variable when it is not specified.
+func (fs *FlagSet) SetEnv(name string, env string) error {
+	if fs.Lookup(name) == nil {
+		return fmt.Errorf("flag %q does not exist", name)
+	}
+	fs.envs[name] = env
+	return nil
+}
+
+// applyEnvs sets the values of the environment variables to the flags which are not specified.
+func (fs *FlagSet) applyEnvs() error {
+	for name, env := range fs.envs {
+		f := fs.Lookup(name)
+		if f == nil || f.Changed {
+			continue
+		}
+		v, ok := os.LookupEnv(env)
+		if !ok {
+			continue
+		}
+		if err := fs.Set(name, v); err != nil {
+			return fmt.Errorf("invalid value %q of $%s for --%s: %w", v, env, name, err)
+		}
+		fs.flagSources[name] = Source{Kind: SourceEnv, Origin: "$" + env}
+	}
+	return nil
 }
 
 // ArgString returns pointer to set n'th non-flag argument after parsing.
 func (fs *FlagSet) ArgString(n int, name string, usage string) *string {
 	p := new(string)
+	fs.ArgStringVar(p, n, name, usage)
+	return p
+}
+
+// ArgStringVar is like ArgString, but sets the argument to p.
+func (fs *FlagSet) ArgStringVar(p *string, n int, name string, usage string) {
 	fs.args[n] = Arg{
 		Name:  name,
 		Usage: usage,
 		Index: n,
 		Value: p,
 	}
-	return p
+}
+
+// Arguments returns the arguments defined by ArgString in the order of the index.
+func (fs *FlagSet) Arguments() []Arg {
+	args := make([]Arg, 0, len(fs.args))
+	for _, a := range fs.args {
+		args = append(args, a)
+	}
+	sort.Slice(args, func(i, j int) bool {
+		return args[i].Index < args[j].Index
+	})
+	return args
 }
@@ -0,0 +1,121 @@
+package wflag
+
+import (
+	"testing"
+
+	fv "github.com/kmio11/flag-validator/pflag-validator"
+	flag "github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlagSet_ParseWithInherited(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		rules       []fv.Rule
+		wantErr     string
+		wantOut     string
+		wantVerbose bool
+		wantQuiet   string
+		wantArgs    []string
+	}{
+		{
+			name:        "inherited flags are accepted",
+			args:        []string{"--verbose", "-o", "a.out", "pkg"},
+			wantOut:     "a.out",
+			wantVerbose: true,
+			wantArgs:    []string{"pkg"},
+		},
+		{
+			name:     "own flag takes precedence over inherited one of the same name",
+			args:     []string{"--quiet", "pkg"},
+			wantOut:  "",
+			wantArgs: []string{"pkg"},
+		},
+		{
+			name:    "inherited flag whose shorthand is used by own flag is not available",
+			args:    []string{"--version"},
+			wantErr: "unknown flag: --version",
+		},
+		{
+			name:    "rules are evaluated with inherited flags",
+			args:    []string{"pkg"},
+			rules:   []fv.Rule{fv.Flag("verbose").Required()},
+			wantErr: "The flag [--verbose] is required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inherited := flag.NewFlagSet("parent", flag.ContinueOnError)
+			verbose := inherited.Bool("verbose", false, "verbose output")
+			quiet := inherited.String("quiet", "parent", "inherited flag of the same name")
+			inherited.BoolP("version", "o", false, "inherited flag whose shorthand conflicts")
+
+			fs := NewFlagSet("child", flag.ContinueOnError)
+			out := fs.StringP("out", "o", "", "output file")
+			fs.Bool("quiet", false, "own flag")
+			fs.SetValidationRules(tt.rules...)
+
+			err := fs.ParseWithInherited(tt.args, inherited)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantOut, *out)
+			assert.Equal(t, tt.wantVerbose, *verbose)
+			assert.Equal(t, "parent", *quiet)
+			assert.Equal(t, tt.wantArgs, fs.Args())
+
+			// the inherited flags are not added to the FlagSet.
+			assert.Nil(t, fs.Lookup("verbose"))
+			names := []string{}
+			fs.VisitAll(func(f *flag.Flag) { names = append(names, f.Name) })
+			assert.ElementsMatch(t, []string{"out", "quiet"}, names)
+		})
+	}
+}
+
+func TestFlagSet_Reset_inherited(t *testing.T) {
+	inherited := flag.NewFlagSet("parent", flag.ContinueOnError)
+	inherited.Bool("verbose", false, "verbose output")
+	fs := NewFlagSet("child", flag.ContinueOnError)
+
+	assert.NoError(t, fs.ParseWithInherited([]string{"--verbose", "pkg"}, inherited))
+	assert.True(t, fs.Parsed())
+	assert.Equal(t, []string{"pkg"}, fs.Args())
+
+	fs.Reset()
+	assert.False(t, fs.Parsed())
+	assert.Empty(t, fs.Args())
+	assert.NoError(t, fs.Parse([]string{}))
+	assert.Nil(t, fs.Lookup("verbose"))
+}
+
+func TestFlagSet_Reset_defaults(t *testing.T) {
+	fs := NewFlagSet("test", flag.ContinueOnError)
+	tags := fs.StringSlice("tags", []string{"a,b", "c"}, "tags")
+	labels := fs.StringToString("labels", map[string]string{"k": "v"}, "labels")
+	name := fs.String("name", "def", "name")
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, fs.Parse([]string{"--tags", "x", "--labels", "k2=v2", "--name", "n"}))
+		assert.Equal(t, []string{"x"}, *tags)
+		assert.Equal(t, map[string]string{"k2": "v2"}, *labels)
+		assert.Equal(t, "n", *name)
+
+		fs.Reset()
+		assert.Equal(t, []string{"a,b", "c"}, *tags)
+		assert.Equal(t, map[string]string{"k": "v"}, *labels)
+		assert.Equal(t, "def", *name)
+	}
+}
+
+func TestFlagSet_Reset_changedBeforeSaved(t *testing.T) {
+	fs := NewFlagSet("test", flag.ContinueOnError)
+	tags := fs.StringSlice("tags", []string{"a,b", "c"}, "tags")
+	assert.NoError(t, fs.Set("tags", "x"))
+
+	fs.Reset()
+	assert.Equal(t, []string{"a,b", "c"}, *tags)
+}
@@ -0,0 +1,240 @@
+package wflag
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/kmio11/mycmd/internal/term"
+	flag "github.com/spf13/pflag"
+)
+
+// ErrNoInput is returned when the input stream is closed while prompting.
+var ErrNoInput = errors.New("no input for prompt")
+
+// Prompt describes how to ask for a value of a flag or an argument.
+type Prompt struct {
+	// Message is shown to the user. If empty, the usage of the flag or argument is used.
+	Message string
+	// Default is used when the user enters an empty line.
+	Default string
+	// Choices restricts the value to one of them.
+	Choices []string
+	// Secret hides the input, e.g. for passwords.
+	Secret bool
+}
+
+// Prompter asks the user for the values of required flags and arguments
+// which are missing after parsing.
+type Prompter struct {
+	In  io.Reader
+	Out io.Writer
+	// Enabled is called after the command line is parsed.
+	// When it returns false, the missing values are reported as errors as usual.
+	Enabled func() bool
+}
+
+func (p *Prompter) enabled() bool {
+	return p != nil && (p.Enabled == nil || p.Enabled())
+}
+
+// SetPrompter enables prompting for missing values. nil disables it.
+func (fs *FlagSet) SetPrompter(p *Prompter) {
+	fs.prompter = p
+}
+
+// MarkRequired makes the named flag required.
+// The missing flag is reported as an error, or prompted when a Prompter is set.
+func (fs *FlagSet) MarkRequired(name string) error {
+	if fs.Lookup(name) == nil {
+		return fmt.Errorf("flag %q does not exist", name)
+	}
+	fs.required = append(fs.required, name)
+	return nil
+}
+
+// IsRequired reports whether the named flag is marked required by MarkRequired.
+func (fs *FlagSet) IsRequired(name string) bool {
+	return contains(fs.required, name)
+}
+
+// MarkArgRequired makes n'th argument defined by ArgString required.
+// It is prompted when it is missing and the prompt is enabled.
+func (fs *FlagSet) MarkArgRequired(n int) error {
+	a, ok := fs.args[n]
+	if !ok {
+		return fmt.Errorf("argument %d does not exist", n)
+	}
+	a.Required = true
+	fs.args[n] = a
+	return nil
+}
+
+// SetPrompt sets how to prompt for the named flag.
+func (fs *FlagSet) SetPrompt(name string, p Prompt) error {
+	if fs.Lookup(name) == nil {
+		return fmt.Errorf("flag %q does not exist", name)
+	}
+	fs.flagPrompts[name] = p
+	return nil
+}
+
+// SetArgPrompt sets how to prompt for n'th argument.
+func (fs *FlagSet) SetArgPrompt(n int, p Prompt) error {
+	a, ok := fs.args[n]
+	if !ok {
+		return fmt.Errorf("argument %d does not exist", n)
+	}
+	a.Prompt = &p
+	fs.args[n] = a
+	return nil
+}
+
+// promptMissing asks for the required flags and arguments which are not specified.
+func (fs *FlagSet) promptMissing() error {
+	for _, name := range fs.required {
+		f := fs.Lookup(name)
+		if f == nil || f.Changed {
+			continue
+		}
+		p, ok := fs.flagPrompts[name]
+		if !ok {
+			p = Prompt{}
+		}
+		if p.Message == "" {
+			p.Message = fmt.Sprintf("--%s (%s)", f.Name, f.Usage)
+		}
+		if p.Default == "" && !isZeroDefault(f) {
+			p.Default = f.DefValue
+		}
+		v, err := fs.prompter.ask(p)
+		if err != nil {
+			return err
+		}
+		if err := fs.Set(name, v); err != nil {
+			return err
+		}
+		fs.flagSources[name] = Source{Kind: SourcePrompt}
+	}
+
+	// arguments can be appended only in order.
+	indexes := []int{}
+	for i := range fs.args {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	added := []string{}
+	for _, i := range indexes {
+		if i < fs.NArg() {
+			continue
+		}
+		a := fs.args[i]
+		if !a.Required || i != fs.NArg()+len(added) {
+			break
+		}
+		p := Prompt{Message: fmt.Sprintf("%s (%s)", a.Name, a.Usage)}
+		if a.Prompt != nil {
+			p = *a.Prompt
+			if p.Message == "" {
+				p.Message = fmt.Sprintf("%s (%s)", a.Name, a.Usage)
+			}
+		}
+		v, err := fs.prompter.ask(p)
+		if err != nil {
+			return err
+		}
+		added = append(added, v)
+		fs.argSources[i] = Source{Kind: SourcePrompt}
+	}
+	if len(added) == 0 {
+		return nil
+	}
+
+	// pflag.FlagSet has no setter of the arguments, so parse them again.
+	return fs.parsing.Parse(append(append([]string{"--"}, fs.Args()...), added...))
+}
+
+func isZeroDefault(f *flag.Flag) bool {
+	switch f.DefValue {
+	case "", "0", "false", "[]":
+		return true
+	}
+	return false
+}
+
+func (p *Prompter) ask(prompt Prompt) (string, error) {
+	label := prompt.Message
+	if len(prompt.Choices) > 0 {
+		label = fmt.Sprintf("%s [%s]", label, strings.Join(prompt.Choices, "/"))
+	}
+	if prompt.Default != "" && !prompt.Secret {
+		label = fmt.Sprintf("%s (default: %s)", label, prompt.Default)
+	}
+
+	for {
+		fmt.Fprintf(p.Out, "%s: ", label)
+		v, err := p.readLine(prompt.Secret)
+		if err != nil {
+			return "", err
+		}
+		if v == "" {
+			v = prompt.Default
+		}
+		if v == "" {
+			fmt.Fprintln(p.Out, "a value is required.")
+			continue
+		}
+		if len(prompt.Choices) > 0 && !contains(prompt.Choices, v) {
+			fmt.Fprintf(p.Out, "%q is not a valid choice.\n", v)
+			continue
+		}
+		return v, nil
+	}
+}
+
+// readLine reads a line byte by byte not to consume the input after the line.
+func (p *Prompter) readLine(secret bool) (string, error) {
+	if f, ok := p.In.(*os.File); ok && secret {
+		if restore, err := term.DisableEcho(f.Fd()); err == nil {
+			defer func() {
+				_ = restore()
+				fmt.Fprintln(p.Out)
+			}()
+		}
+	}
+
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := p.In.Read(b)
+		if n > 0 {
+			if b[0] == '\n' {
+				return strings.TrimRight(string(line), "\r"), nil
+			}
+			line = append(line, b[0])
+			continue
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				if len(line) > 0 {
+					return string(line), nil
+				}
+				return "", ErrNoInput
+			}
+			return "", err
+		}
+	}
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
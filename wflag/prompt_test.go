@@ -0,0 +1,94 @@
+package wflag
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	flag "github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlagSet_Parse_prompt(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		input      string
+		enabled    bool
+		wantErr    bool
+		wantFormat string
+		wantOut    string
+		wantArg    string
+		wantPrompt string
+	}{
+		{
+			name:       "prompt missing flag and argument",
+			args:       []string{},
+			input:      "\nxml\nyaml\nout.txt\npkg\n",
+			enabled:    true,
+			wantFormat: "yaml",
+			wantOut:    "out.txt",
+			wantArg:    "pkg",
+			wantPrompt: "format [json/yaml]: a value is required.\n" +
+				"format [json/yaml]: \"xml\" is not a valid choice.\n" +
+				"format [json/yaml]: " +
+				"--out (output file) (default: a.out): " +
+				"package (the package): ",
+		},
+		{
+			name:       "use default value",
+			args:       []string{"--format", "json", "pkg"},
+			input:      "\n",
+			enabled:    true,
+			wantFormat: "json",
+			wantOut:    "a.out",
+			wantArg:    "pkg",
+			wantPrompt: "--out (output file) (default: a.out): ",
+		},
+		{
+			name:    "input is closed",
+			args:    []string{},
+			input:   "",
+			enabled: true,
+			wantErr: true,
+		},
+		{
+			name:    "prompt is disabled",
+			args:    []string{"pkg"},
+			input:   "json\n",
+			enabled: false,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFlagSet("test", flag.ContinueOnError)
+			format := fs.String("format", "", "output format")
+			out := fs.String("out", "a.out", "output file")
+			arg := fs.ArgString(0, "package", "the package")
+			assert.NoError(t, fs.MarkRequired("format"))
+			assert.NoError(t, fs.MarkRequired("out"))
+			assert.NoError(t, fs.MarkArgRequired(0))
+			assert.NoError(t, fs.SetPrompt("format", Prompt{Message: "format", Choices: []string{"json", "yaml"}}))
+
+			prompt := new(bytes.Buffer)
+			fs.SetPrompter(&Prompter{
+				In:      strings.NewReader(tt.input),
+				Out:     prompt,
+				Enabled: func() bool { return tt.enabled },
+			})
+
+			err := fs.Parse(tt.args)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantFormat, *format)
+			assert.Equal(t, tt.wantOut, *out)
+			assert.Equal(t, tt.wantArg, *arg)
+			assert.Equal(t, tt.wantPrompt, prompt.String())
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package wflag
+
+import "fmt"
+
+// SourceKind is the kind of the source of a flag or an argument value.
+type SourceKind int
+
+const (
+	// SourceDefault means that the value is not specified.
+	SourceDefault SourceKind = iota
+	SourceCommandLine
+	SourceEnv
+	SourceConfig
+	SourcePrompt
+)
+
+func (k SourceKind) String() string {
+	switch k {
+	case SourceDefault:
+		return "default"
+	case SourceCommandLine:
+		return "command line"
+	case SourceEnv:
+		return "env"
+	case SourceConfig:
+		return "config"
+	case SourcePrompt:
+		return "prompt"
+	}
+	return fmt.Sprintf("SourceKind(%d)", int(k))
+}
+
+// Source is where the value of a flag or an argument comes from.
+type Source struct {
+	Kind SourceKind
+	// Origin is the detail of the source, e.g. the environment variable or the path of the config file.
+	Origin string
+}
+
+func (s Source) String() string {
+	if s.Origin == "" {
+		return s.Kind.String()
+	}
+	return fmt.Sprintf("%s (%s)", s.Kind, s.Origin)
+}
+
+// Source returns the source of the value of the named flag, which is recorded by parsing.
+// The zero Source, whose Kind is SourceDefault, is returned for the flags not specified.
+func (fs *FlagSet) Source(name string) Source {
+	return fs.flagSources[name]
+}
+
+// ArgSource returns the source of n'th argument defined by ArgString, which is recorded by parsing.
+func (fs *FlagSet) ArgSource(n int) Source {
+	return fs.argSources[n]
+}
+
+// SetFrom sets the value of the named flag and records its source.
+// It is intended for the values from outside of the command line, e.g. a config file,
+// and should be called after parsing for the flags whose source is SourceDefault, like the environment variables are applied.
+func (fs *FlagSet) SetFrom(name string, value string, src Source) error {
+	if err := fs.Set(name, value); err != nil {
+		return err
+	}
+	fs.flagSources[name] = src
+	return nil
+}
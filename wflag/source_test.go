@@ -0,0 +1,42 @@
+package wflag
+
+import (
+	"strings"
+	"testing"
+
+	flag "github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlagSet_Source(t *testing.T) {
+	t.Setenv("TEST_OUT", "env.out")
+
+	fs := NewFlagSet("test", flag.ContinueOnError)
+	fs.String("format", "", "output format")
+	fs.String("out", "a.out", "output file")
+	fs.String("level", "info", "log level")
+	fs.Bool("race", false, "enable race detection")
+	fs.ArgString(0, "package", "the package")
+	fs.ArgString(1, "target", "the target")
+	assert.NoError(t, fs.SetEnv("out", "TEST_OUT"))
+	assert.NoError(t, fs.MarkRequired("format"))
+	assert.NoError(t, fs.MarkArgRequired(1))
+	fs.SetPrompter(&Prompter{
+		In:  strings.NewReader("json\nlinux\n"),
+		Out: new(strings.Builder),
+	})
+
+	assert.NoError(t, fs.Parse([]string{"--race", "pkg"}))
+	assert.NoError(t, fs.SetFrom("level", "debug", Source{Kind: SourceConfig, Origin: "config.yaml"}))
+
+	assert.Equal(t, "prompt", fs.Source("format").String())
+	assert.Equal(t, "env ($TEST_OUT)", fs.Source("out").String())
+	assert.Equal(t, "config (config.yaml)", fs.Source("level").String())
+	assert.Equal(t, "command line", fs.Source("race").String())
+	assert.Equal(t, "command line", fs.ArgSource(0).String())
+	assert.Equal(t, "prompt", fs.ArgSource(1).String())
+
+	fs.Reset()
+	assert.Equal(t, SourceDefault, fs.Source("race").Kind)
+	assert.Equal(t, SourceDefault, fs.ArgSource(0).Kind)
+}
@@ -0,0 +1,53 @@
+package wflag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// enumValue is a flag value which accepts only one of the choices.
+type enumValue struct {
+	value   *string
+	def     string
+	choices []string
+}
+
+func (v *enumValue) String() string {
+	return *v.value
+}
+
+func (v *enumValue) Set(s string) error {
+	if s == v.def {
+		*v.value = s
+		return nil
+	}
+	for _, c := range v.choices {
+		if s == c {
+			*v.value = s
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of %s", strings.Join(v.choices, ", "))
+}
+
+func (v *enumValue) Type() string {
+	return "string"
+}
+
+// Choices returns the values which the flag accepts.
+func (v *enumValue) Choices() []string {
+	return v.choices
+}
+
+// Enum defines a string flag which accepts only one of the choices.
+func (fs *FlagSet) Enum(name string, value string, choices []string, usage string) *string {
+	return fs.EnumP(name, "", value, choices, usage)
+}
+
+// EnumP is like Enum, but accepts a shorthand letter.
+func (fs *FlagSet) EnumP(name, shorthand string, value string, choices []string, usage string) *string {
+	p := new(string)
+	*p = value
+	fs.VarP(&enumValue{value: p, def: value, choices: choices}, name, shorthand, usage)
+	return p
+}